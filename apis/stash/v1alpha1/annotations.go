@@ -6,4 +6,13 @@ const (
 	VersionTag               = ResticKey + "/tag"
 	// ResourceVersion will be used to trigger restarts for ReplicaSet and RC pods
 	ResourceHash = ResticKey + "/resource-hash"
+	// BackupTrigger on a Restic requests an immediate backup. Value is the request time in RFC3339 format.
+	BackupTrigger = ResticKey + "/backup-trigger"
+	// BackupDispatch on a Repository requests a backup from its sidecar, when Restic is scheduled by operator.
+	// Value is the dispatch time in RFC3339 format.
+	BackupDispatch = ResticKey + "/backup-dispatch"
+	// BackupDispatchCompleted on a Repository is set by sidecar to the value of BackupDispatch when the backup is done.
+	BackupDispatchCompleted = ResticKey + "/backup-dispatch-completed"
+	// NodeName on a Repository is the node of the sidecar that backs up the Repository.
+	NodeName = ResticKey + "/node-name"
 )
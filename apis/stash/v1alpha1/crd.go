@@ -58,3 +58,21 @@ func (c Repository) CustomResourceDefinition() *apiextensions.CustomResourceDefi
 		GetOpenAPIDefinitions: GetOpenAPIDefinitions,
 	})
 }
+
+func (c BackupSession) CustomResourceDefinition() *apiextensions.CustomResourceDefinition {
+	return crdutils.NewCustomResourceDefinition(crdutils.Config{
+		Group:         SchemeGroupVersion.Group,
+		Version:       SchemeGroupVersion.Version,
+		Plural:        ResourcePluralBackupSession,
+		Singular:      ResourceSingularBackupSession,
+		Kind:          ResourceKindBackupSession,
+		ShortNames:    []string{"bs"},
+		ResourceScope: string(apiextensions.NamespaceScoped),
+		Labels: crdutils.Labels{
+			LabelsMap: map[string]string{"app": "stash"},
+		},
+		SpecDefinitionName:    "github.com/appscode/stash/apis/stash/v1alpha1.BackupSession",
+		EnableValidation:      true,
+		GetOpenAPIDefinitions: GetOpenAPIDefinitions,
+	})
+}
@@ -1,10 +1,14 @@
 package v1alpha1
 
 import (
+	"fmt"
 	"hash/fnv"
 	"strconv"
+	"strings"
+	"time"
 
 	core "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	hashutil "k8s.io/kubernetes/pkg/util/hash"
 )
 
@@ -26,3 +30,122 @@ func (r Restic) GetSpecHash() string {
 	hashutil.DeepHashObject(hash, r.Spec)
 	return strconv.FormatUint(hash.Sum64(), 10)
 }
+
+// GetCondition returns the condition of Repository with type t, nil if absent.
+func (s RepositoryStatus) GetCondition(t RepositoryConditionType) *RepositoryCondition {
+	for i := range s.Conditions {
+		if s.Conditions[i].Type == t {
+			return &s.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// SetRepositoryCondition adds or updates cond in conditions. LastTransitionTime is updated only if status changed.
+func SetRepositoryCondition(conditions []RepositoryCondition, cond RepositoryCondition) []RepositoryCondition {
+	for i := range conditions {
+		if conditions[i].Type != cond.Type {
+			continue
+		}
+		if conditions[i].Status == cond.Status {
+			cond.LastTransitionTime = conditions[i].LastTransitionTime
+		} else if cond.LastTransitionTime.IsZero() {
+			cond.LastTransitionTime = metav1.Now()
+		}
+		conditions[i] = cond
+		return conditions
+	}
+	if cond.LastTransitionTime.IsZero() {
+		cond.LastTransitionTime = metav1.Now()
+	}
+	return append(conditions, cond)
+}
+
+const blackoutTimeFormat = "15:04"
+
+// Location returns the time zone of schedule and blackout windows.
+func (r ResticSpec) Location() (*time.Location, error) {
+	if r.TimeZone == "" {
+		return time.Local, nil
+	}
+	return time.LoadLocation(r.TimeZone)
+}
+
+// CronSchedule returns schedule in the time zone of Restic, in the format accepted by cron.
+func (r ResticSpec) CronSchedule() string {
+	if r.TimeZone == "" || strings.HasPrefix(r.Schedule, "TZ=") {
+		return r.Schedule
+	}
+	return "TZ=" + r.TimeZone + " " + r.Schedule
+}
+
+// ActiveBlackoutWindow returns the blackout window containing t, nil if t is not in any blackout window.
+func (r ResticSpec) ActiveBlackoutWindow(t time.Time) (*BlackoutWindow, error) {
+	if len(r.BlackoutWindows) == 0 {
+		return nil, nil
+	}
+	loc, err := r.Location()
+	if err != nil {
+		return nil, err
+	}
+	t = t.In(loc)
+	for i := range r.BlackoutWindows {
+		ok, err := r.BlackoutWindows[i].Contains(t)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return &r.BlackoutWindows[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// Contains returns true if t, in the time zone of the window, is in the window.
+func (w BlackoutWindow) Contains(t time.Time) (bool, error) {
+	start, err := time.Parse(blackoutTimeFormat, w.Start)
+	if err != nil {
+		return false, fmt.Errorf("invalid start %s", w.Start)
+	}
+	end, err := time.Parse(blackoutTimeFormat, w.End)
+	if err != nil {
+		return false, fmt.Errorf("invalid end %s", w.End)
+	}
+	startMin := start.Hour()*60 + start.Minute()
+	endMin := end.Hour()*60 + end.Minute()
+	cur := t.Hour()*60 + t.Minute()
+
+	if startMin < endMin {
+		return w.onDay(t.Weekday()) && cur >= startMin && cur < endMin, nil
+	}
+	// window ends on the next day
+	return (w.onDay(t.Weekday()) && cur >= startMin) || (w.onDay(t.AddDate(0, 0, -1).Weekday()) && cur < endMin), nil
+}
+
+func (w BlackoutWindow) String() string {
+	if len(w.Days) == 0 {
+		return fmt.Sprintf("%s-%s", w.Start, w.End)
+	}
+	return fmt.Sprintf("%s %s-%s", strings.Join(w.Days, ","), w.Start, w.End)
+}
+
+func (w BlackoutWindow) onDay(day time.Weekday) bool {
+	if len(w.Days) == 0 {
+		return true
+	}
+	for _, d := range w.Days {
+		if wd, ok := parseWeekday(d); ok && wd == day {
+			return true
+		}
+	}
+	return false
+}
+
+func parseWeekday(s string) (time.Weekday, bool) {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if strings.EqualFold(s, d.String()) || strings.EqualFold(s, d.String()[:3]) {
+			return d, true
+		}
+	}
+	return time.Sunday, false
+}
@@ -0,0 +1,159 @@
+package v1alpha1
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBlackoutWindowContains(t *testing.T) {
+	// 2018-04-13 is a Friday
+	at := func(day, hour, min int) time.Time {
+		return time.Date(2018, 4, day, hour, min, 0, 0, time.UTC)
+	}
+	cases := []struct {
+		name     string
+		window   BlackoutWindow
+		t        time.Time
+		expected bool
+	}{
+		{"before start", BlackoutWindow{Start: "01:00", End: "05:00"}, at(13, 0, 59), false},
+		{"at start", BlackoutWindow{Start: "01:00", End: "05:00"}, at(13, 1, 0), true},
+		{"before end", BlackoutWindow{Start: "01:00", End: "05:00"}, at(13, 4, 59), true},
+		{"at end", BlackoutWindow{Start: "01:00", End: "05:00"}, at(13, 5, 0), false},
+
+		{"on day", BlackoutWindow{Days: []string{"Monday", "Fri"}, Start: "09:00", End: "17:00"}, at(13, 10, 0), true},
+		{"day is case insensitive", BlackoutWindow{Days: []string{"fri"}, Start: "09:00", End: "17:00"}, at(13, 10, 0), true},
+		{"other day", BlackoutWindow{Days: []string{"Monday"}, Start: "09:00", End: "17:00"}, at(13, 10, 0), false},
+
+		{"crossing midnight before start", BlackoutWindow{Days: []string{"Friday"}, Start: "22:00", End: "02:00"}, at(13, 21, 59), false},
+		{"crossing midnight at start", BlackoutWindow{Days: []string{"Friday"}, Start: "22:00", End: "02:00"}, at(13, 22, 0), true},
+		{"crossing midnight before midnight", BlackoutWindow{Days: []string{"Friday"}, Start: "22:00", End: "02:00"}, at(13, 23, 59), true},
+		{"crossing midnight next day", BlackoutWindow{Days: []string{"Friday"}, Start: "22:00", End: "02:00"}, at(14, 1, 59), true},
+		{"crossing midnight at end", BlackoutWindow{Days: []string{"Friday"}, Start: "22:00", End: "02:00"}, at(14, 2, 0), false},
+		{"crossing midnight started on previous day", BlackoutWindow{Days: []string{"Friday"}, Start: "22:00", End: "02:00"}, at(13, 1, 0), false},
+		{"crossing midnight next day evening", BlackoutWindow{Days: []string{"Friday"}, Start: "22:00", End: "02:00"}, at(14, 23, 0), false},
+		{"crossing midnight every day", BlackoutWindow{Start: "22:00", End: "02:00"}, at(16, 0, 30), true},
+
+		{"whole day", BlackoutWindow{Days: []string{"Sat"}, Start: "00:00", End: "00:00"}, at(14, 13, 0), true},
+		{"whole day other day", BlackoutWindow{Days: []string{"Sat"}, Start: "00:00", End: "00:00"}, at(15, 13, 0), false},
+	}
+	for _, c := range cases {
+		got, err := c.window.Contains(c.t)
+		if err != nil {
+			t.Errorf("%s: %s", c.name, err)
+			continue
+		}
+		if got != c.expected {
+			t.Errorf("%s: expected %s to contain %s: %v, got %v", c.name, c.window, c.t.Format("Mon 15:04"), c.expected, got)
+		}
+	}
+}
+
+func TestBlackoutWindowContainsInvalid(t *testing.T) {
+	for _, w := range []BlackoutWindow{
+		{Start: "25:00", End: "02:00"},
+		{Start: "22:00", End: "2am"},
+		{Start: "", End: "02:00"},
+	} {
+		if _, err := w.Contains(time.Now()); err == nil {
+			t.Errorf("expected error for window %s", w)
+		}
+	}
+}
+
+func TestActiveBlackoutWindow(t *testing.T) {
+	windows := []BlackoutWindow{
+		{Days: []string{"Friday"}, Start: "22:00", End: "02:00"},
+		{Days: []string{"Sunday"}, Start: "10:00", End: "12:00"},
+	}
+	cases := []struct {
+		name     string
+		spec     ResticSpec
+		t        time.Time
+		expected *BlackoutWindow
+		err      bool
+	}{
+		{
+			name: "no windows",
+			spec: ResticSpec{TimeZone: "Asia/Tokyo"},
+			t:    time.Date(2018, 4, 13, 13, 30, 0, 0, time.UTC),
+		},
+		{
+			// Friday 22:30 in Tokyo
+			name:     "in time zone",
+			spec:     ResticSpec{TimeZone: "Asia/Tokyo", BlackoutWindows: windows},
+			t:        time.Date(2018, 4, 13, 13, 30, 0, 0, time.UTC),
+			expected: &windows[0],
+		},
+		{
+			// Saturday 01:59 in Tokyo, Friday 16:59 in UTC
+			name:     "in time zone crossing midnight",
+			spec:     ResticSpec{TimeZone: "Asia/Tokyo", BlackoutWindows: windows},
+			t:        time.Date(2018, 4, 13, 16, 59, 0, 0, time.UTC),
+			expected: &windows[0],
+		},
+		{
+			// Friday 22:30 in UTC, Saturday 07:30 in Tokyo
+			name: "outside in time zone",
+			spec: ResticSpec{TimeZone: "Asia/Tokyo", BlackoutWindows: windows},
+			t:    time.Date(2018, 4, 13, 22, 30, 0, 0, time.UTC),
+		},
+		{
+			// Sunday 11:00 in Tokyo
+			name:     "second window",
+			spec:     ResticSpec{TimeZone: "Asia/Tokyo", BlackoutWindows: windows},
+			t:        time.Date(2018, 4, 15, 2, 0, 0, 0, time.UTC),
+			expected: &windows[1],
+		},
+		{
+			// daylight saving time starts at 02:00, 01:30 in UTC is 03:30 in Berlin
+			name:     "daylight saving time",
+			spec:     ResticSpec{TimeZone: "Europe/Berlin", BlackoutWindows: []BlackoutWindow{{Start: "03:00", End: "04:00"}}},
+			t:        time.Date(2018, 3, 25, 1, 30, 0, 0, time.UTC),
+			expected: &BlackoutWindow{Start: "03:00", End: "04:00"},
+		},
+		{
+			name: "invalid time zone",
+			spec: ResticSpec{TimeZone: "Mars/Olympus", BlackoutWindows: windows},
+			t:    time.Date(2018, 4, 13, 13, 30, 0, 0, time.UTC),
+			err:  true,
+		},
+	}
+	for _, c := range cases {
+		got, err := c.spec.ActiveBlackoutWindow(c.t)
+		if c.err {
+			if err == nil {
+				t.Errorf("%s: expected error", c.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %s", c.name, err)
+			continue
+		}
+		switch {
+		case c.expected == nil && got != nil:
+			t.Errorf("%s: expected no window, got %s", c.name, got)
+		case c.expected != nil && got == nil:
+			t.Errorf("%s: expected window %s, got none", c.name, c.expected)
+		case c.expected != nil && got.String() != c.expected.String():
+			t.Errorf("%s: expected window %s, got %s", c.name, c.expected, got)
+		}
+	}
+}
+
+func TestCronSchedule(t *testing.T) {
+	cases := []struct {
+		spec     ResticSpec
+		expected string
+	}{
+		{ResticSpec{Schedule: "@every 1h"}, "@every 1h"},
+		{ResticSpec{Schedule: "0 2 * * *", TimeZone: "Asia/Tokyo"}, "TZ=Asia/Tokyo 0 2 * * *"},
+		{ResticSpec{Schedule: "TZ=UTC 0 2 * * *", TimeZone: "Asia/Tokyo"}, "TZ=UTC 0 2 * * *"},
+	}
+	for _, c := range cases {
+		if got := c.spec.CronSchedule(); got != c.expected {
+			t.Errorf("expected %q, got %q", c.expected, got)
+		}
+	}
+}
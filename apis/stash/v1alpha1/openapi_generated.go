@@ -45,6 +45,20 @@ func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenA
 								Format: "",
 							},
 						},
+						"endpointSuffix": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Endpoint suffix of the storage service. Default value is 'core.windows.net' (public Azure cloud). Use 'core.usgovcloudapi.net' for Azure Government, 'core.chinacloudapi.cn' for Azure China or the host of a local emulator like Azurite.",
+								Type:        []string{"string"},
+								Format:      "",
+							},
+						},
+						"endpoint": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Endpoint of the blob service of the storage account, eg: 'https://<account>.blob.core.windows.net'. restic only accesses https://<account>.blob.<suffix>, so endpoints with the account in the path, like 'http://azurite:10000/devstoreaccount1' of Azurite, are not supported. Conflicts with EndpointSuffix.",
+								Type:        []string{"string"},
+								Format:      "",
+							},
+						},
 					},
 				},
 			},
@@ -111,11 +125,351 @@ func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenA
 								Ref: ref("github.com/appscode/stash/apis/stash/v1alpha1.B2Spec"),
 							},
 						},
+						"rest": {
+							SchemaProps: spec.SchemaProps{
+								Ref: ref("github.com/appscode/stash/apis/stash/v1alpha1.RestServerSpec"),
+							},
+						},
+						"sftp": {
+							SchemaProps: spec.SchemaProps{
+								Ref: ref("github.com/appscode/stash/apis/stash/v1alpha1.SFTPSpec"),
+							},
+						},
+						"rclone": {
+							SchemaProps: spec.SchemaProps{
+								Ref: ref("github.com/appscode/stash/apis/stash/v1alpha1.RcloneSpec"),
+							},
+						},
+					},
+				},
+			},
+			Dependencies: []string{
+				"github.com/appscode/stash/apis/stash/v1alpha1.AzureSpec", "github.com/appscode/stash/apis/stash/v1alpha1.B2Spec", "github.com/appscode/stash/apis/stash/v1alpha1.GCSSpec", "github.com/appscode/stash/apis/stash/v1alpha1.LocalSpec", "github.com/appscode/stash/apis/stash/v1alpha1.RcloneSpec", "github.com/appscode/stash/apis/stash/v1alpha1.RestServerSpec", "github.com/appscode/stash/apis/stash/v1alpha1.S3Spec", "github.com/appscode/stash/apis/stash/v1alpha1.SFTPSpec", "github.com/appscode/stash/apis/stash/v1alpha1.SwiftSpec"},
+		},
+		"github.com/appscode/stash/apis/stash/v1alpha1.BackupHooks": {
+			Schema: spec.Schema{
+				SchemaProps: spec.SchemaProps{
+					Properties: map[string]spec.Schema{
+						"preBackup": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Hooks executed before backup. Backup is skipped if a hook with Abort failure policy fails.",
+								Type:        []string{"array"},
+								Items: &spec.SchemaOrArray{
+									Schema: &spec.Schema{
+										SchemaProps: spec.SchemaProps{
+											Ref: ref("github.com/appscode/stash/apis/stash/v1alpha1.Hook"),
+										},
+									},
+								},
+							},
+						},
+						"postBackup": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Hooks executed after backup, even if backup or a pre backup hook failed.",
+								Type:        []string{"array"},
+								Items: &spec.SchemaOrArray{
+									Schema: &spec.Schema{
+										SchemaProps: spec.SchemaProps{
+											Ref: ref("github.com/appscode/stash/apis/stash/v1alpha1.Hook"),
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			Dependencies: []string{
+				"github.com/appscode/stash/apis/stash/v1alpha1.Hook"},
+		},
+		"github.com/appscode/stash/apis/stash/v1alpha1.BackupSession": {
+			Schema: spec.Schema{
+				SchemaProps: spec.SchemaProps{
+					Description: "BackupSession records a run of backup by a stash sidecar.",
+					Properties: map[string]spec.Schema{
+						"kind": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Kind is a string value representing the REST resource this object represents. Servers may infer this from the endpoint the client submits requests to. Cannot be updated. In CamelCase. More info: https://git.k8s.io/community/contributors/devel/api-conventions.md#types-kinds",
+								Type:        []string{"string"},
+								Format:      "",
+							},
+						},
+						"apiVersion": {
+							SchemaProps: spec.SchemaProps{
+								Description: "APIVersion defines the versioned schema of this representation of an object. Servers should convert recognized schemas to the latest internal value, and may reject unrecognized values. More info: https://git.k8s.io/community/contributors/devel/api-conventions.md#resources",
+								Type:        []string{"string"},
+								Format:      "",
+							},
+						},
+						"metadata": {
+							SchemaProps: spec.SchemaProps{
+								Ref: ref("k8s.io/apimachinery/pkg/apis/meta/v1.ObjectMeta"),
+							},
+						},
+						"spec": {
+							SchemaProps: spec.SchemaProps{
+								Ref: ref("github.com/appscode/stash/apis/stash/v1alpha1.BackupSessionSpec"),
+							},
+						},
+						"status": {
+							SchemaProps: spec.SchemaProps{
+								Ref: ref("github.com/appscode/stash/apis/stash/v1alpha1.BackupSessionStatus"),
+							},
+						},
+					},
+				},
+			},
+			Dependencies: []string{
+				"github.com/appscode/stash/apis/stash/v1alpha1.BackupSessionSpec", "github.com/appscode/stash/apis/stash/v1alpha1.BackupSessionStatus", "k8s.io/apimachinery/pkg/apis/meta/v1.ObjectMeta"},
+		},
+		"github.com/appscode/stash/apis/stash/v1alpha1.BackupSessionList": {
+			Schema: spec.Schema{
+				SchemaProps: spec.SchemaProps{
+					Properties: map[string]spec.Schema{
+						"kind": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Kind is a string value representing the REST resource this object represents. Servers may infer this from the endpoint the client submits requests to. Cannot be updated. In CamelCase. More info: https://git.k8s.io/community/contributors/devel/api-conventions.md#types-kinds",
+								Type:        []string{"string"},
+								Format:      "",
+							},
+						},
+						"apiVersion": {
+							SchemaProps: spec.SchemaProps{
+								Description: "APIVersion defines the versioned schema of this representation of an object. Servers should convert recognized schemas to the latest internal value, and may reject unrecognized values. More info: https://git.k8s.io/community/contributors/devel/api-conventions.md#resources",
+								Type:        []string{"string"},
+								Format:      "",
+							},
+						},
+						"metadata": {
+							SchemaProps: spec.SchemaProps{
+								Ref: ref("k8s.io/apimachinery/pkg/apis/meta/v1.ListMeta"),
+							},
+						},
+						"items": {
+							SchemaProps: spec.SchemaProps{
+								Type: []string{"array"},
+								Items: &spec.SchemaOrArray{
+									Schema: &spec.Schema{
+										SchemaProps: spec.SchemaProps{
+											Ref: ref("github.com/appscode/stash/apis/stash/v1alpha1.BackupSession"),
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			Dependencies: []string{
+				"github.com/appscode/stash/apis/stash/v1alpha1.BackupSession", "k8s.io/apimachinery/pkg/apis/meta/v1.ListMeta"},
+		},
+		"github.com/appscode/stash/apis/stash/v1alpha1.BackupSessionSpec": {
+			Schema: spec.Schema{
+				SchemaProps: spec.SchemaProps{
+					Properties: map[string]spec.Schema{
+						"restic": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Name of the Restic used for backup",
+								Type:        []string{"string"},
+								Format:      "",
+							},
+						},
+						"repository": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Name of the Repository where snapshots are stored",
+								Type:        []string{"string"},
+								Format:      "",
+							},
+						},
+						"workload": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Workload backed up in this session",
+								Ref:         ref("github.com/appscode/stash/apis/stash/v1alpha1.LocalTypedReference"),
+							},
+						},
+						"podName": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Pod that ran the backup",
+								Type:        []string{"string"},
+								Format:      "",
+							},
+						},
+						"nodeName": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Node where the pod was running",
+								Type:        []string{"string"},
+								Format:      "",
+							},
+						},
+						"hostname": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Hostname stored in snapshots",
+								Type:        []string{"string"},
+								Format:      "",
+							},
+						},
+					},
+				},
+			},
+			Dependencies: []string{
+				"github.com/appscode/stash/apis/stash/v1alpha1.LocalTypedReference"},
+		},
+		"github.com/appscode/stash/apis/stash/v1alpha1.BackupSessionStatus": {
+			Schema: spec.Schema{
+				SchemaProps: spec.SchemaProps{
+					Properties: map[string]spec.Schema{
+						"phase": {
+							SchemaProps: spec.SchemaProps{
+								Type:   []string{"string"},
+								Format: "",
+							},
+						},
+						"startTime": {
+							SchemaProps: spec.SchemaProps{
+								Ref: ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+							},
+						},
+						"completionTime": {
+							SchemaProps: spec.SchemaProps{
+								Ref: ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+							},
+						},
+						"fileGroups": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Results of the FileGroups processed in this session",
+								Type:        []string{"array"},
+								Items: &spec.SchemaOrArray{
+									Schema: &spec.Schema{
+										SchemaProps: spec.SchemaProps{
+											Ref: ref("github.com/appscode/stash/apis/stash/v1alpha1.FileGroupStats"),
+										},
+									},
+								},
+							},
+						},
+						"error": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Reason of failure",
+								Type:        []string{"string"},
+								Format:      "",
+							},
+						},
+					},
+				},
+			},
+			Dependencies: []string{
+				"github.com/appscode/stash/apis/stash/v1alpha1.FileGroupStats", "k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
+		},
+		"github.com/appscode/stash/apis/stash/v1alpha1.BlackoutWindow": {
+			Schema: spec.Schema{
+				SchemaProps: spec.SchemaProps{
+					Properties: map[string]spec.Schema{
+						"days": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Days of week when the window starts, eg: Monday or Mon. Every day if empty.",
+								Type:        []string{"array"},
+								Items: &spec.SchemaOrArray{
+									Schema: &spec.Schema{
+										SchemaProps: spec.SchemaProps{
+											Type:   []string{"string"},
+											Format: "",
+										},
+									},
+								},
+							},
+						},
+						"start": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Start of the window in HH:MM format",
+								Type:        []string{"string"},
+								Format:      "",
+							},
+						},
+						"end": {
+							SchemaProps: spec.SchemaProps{
+								Description: "End of the window in HH:MM format. A window ending at or before its start ends on the next day.",
+								Type:        []string{"string"},
+								Format:      "",
+							},
+						},
+					},
+					Required: []string{"start", "end"},
+				},
+			},
+			Dependencies: []string{},
+		},
+		"github.com/appscode/stash/apis/stash/v1alpha1.ExecHook": {
+			Schema: spec.Schema{
+				SchemaProps: spec.SchemaProps{
+					Properties: map[string]spec.Schema{
+						"container": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Name of the container where command is executed",
+								Type:        []string{"string"},
+								Format:      "",
+							},
+						},
+						"command": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Command to execute, it is not run in a shell",
+								Type:        []string{"array"},
+								Items: &spec.SchemaOrArray{
+									Schema: &spec.Schema{
+										SchemaProps: spec.SchemaProps{
+											Type:   []string{"string"},
+											Format: "",
+										},
+									},
+								},
+							},
+						},
+					},
+					Required: []string{"container", "command"},
+				},
+			},
+			Dependencies: []string{},
+		},
+		"github.com/appscode/stash/apis/stash/v1alpha1.ExecutionPolicy": {
+			Schema: spec.Schema{
+				SchemaProps: spec.SchemaProps{
+					Properties: map[string]spec.Schema{
+						"timeout": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Maximum duration to backup a FileGroup, including all retries and waiting for the repository lock. restic is killed when exceeded. Does not apply to the maintenance job. No limit by default.",
+								Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Duration"),
+							},
+						},
+						"retries": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Number of times backup of a FileGroup is retried after a transient failure, ie: unreachable backend or timeout. Backup waits for a locked repository without counting attempts.",
+								Type:        []string{"integer"},
+								Format:      "int32",
+							},
+						},
+						"backoff": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Delay before the first retry, doubled for each later retry. Defaults to 30s.",
+								Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Duration"),
+							},
+						},
+						"onFileGroupFailure": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Whether remaining FileGroups are backed up when backup of a FileGroup fails. Defaults to Abort.",
+								Type:        []string{"string"},
+								Format:      "",
+							},
+						},
+						"staleLockTimeout": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Locks of the repository not refreshed by restic for this duration are removed when they block backup, check or maintenance, unless their host is a running pod of the workload. Locks of restic processes of the same pod that no longer run are always removed. Defaults to and must be at least 10m.",
+								Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Duration"),
+							},
+						},
 					},
 				},
 			},
 			Dependencies: []string{
-				"github.com/appscode/stash/apis/stash/v1alpha1.AzureSpec", "github.com/appscode/stash/apis/stash/v1alpha1.B2Spec", "github.com/appscode/stash/apis/stash/v1alpha1.GCSSpec", "github.com/appscode/stash/apis/stash/v1alpha1.LocalSpec", "github.com/appscode/stash/apis/stash/v1alpha1.S3Spec", "github.com/appscode/stash/apis/stash/v1alpha1.SwiftSpec"},
+				"k8s.io/apimachinery/pkg/apis/meta/v1.Duration"},
 		},
 		"github.com/appscode/stash/apis/stash/v1alpha1.FileGroup": {
 			Schema: spec.Schema{
@@ -154,6 +508,64 @@ func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenA
 			},
 			Dependencies: []string{},
 		},
+		"github.com/appscode/stash/apis/stash/v1alpha1.FileGroupStats": {
+			Schema: spec.Schema{
+				SchemaProps: spec.SchemaProps{
+					Properties: map[string]spec.Schema{
+						"path": {
+							SchemaProps: spec.SchemaProps{
+								Type:   []string{"string"},
+								Format: "",
+							},
+						},
+						"snapshotID": {
+							SchemaProps: spec.SchemaProps{
+								Description: "ID of the snapshot taken for this FileGroup",
+								Type:        []string{"string"},
+								Format:      "",
+							},
+						},
+						"filesNew": {
+							SchemaProps: spec.SchemaProps{
+								Type:   []string{"integer"},
+								Format: "int64",
+							},
+						},
+						"filesChanged": {
+							SchemaProps: spec.SchemaProps{
+								Type:   []string{"integer"},
+								Format: "int64",
+							},
+						},
+						"filesUnmodified": {
+							SchemaProps: spec.SchemaProps{
+								Type:   []string{"integer"},
+								Format: "int64",
+							},
+						},
+						"dataAdded": {
+							SchemaProps: spec.SchemaProps{
+								Type:   []string{"integer"},
+								Format: "int64",
+							},
+						},
+						"totalBytesProcessed": {
+							SchemaProps: spec.SchemaProps{
+								Type:   []string{"integer"},
+								Format: "int64",
+							},
+						},
+						"duration": {
+							SchemaProps: spec.SchemaProps{
+								Type:   []string{"string"},
+								Format: "",
+							},
+						},
+					},
+				},
+			},
+			Dependencies: []string{},
+		},
 		"github.com/appscode/stash/apis/stash/v1alpha1.GCSSpec": {
 			Schema: spec.Schema{
 				SchemaProps: spec.SchemaProps{
@@ -164,13 +576,154 @@ func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenA
 								Format: "",
 							},
 						},
-						"prefix": {
+						"prefix": {
+							SchemaProps: spec.SchemaProps{
+								Type:   []string{"string"},
+								Format: "",
+							},
+						},
+					},
+				},
+			},
+			Dependencies: []string{},
+		},
+		"github.com/appscode/stash/apis/stash/v1alpha1.HTTPHook": {
+			Schema: spec.Schema{
+				SchemaProps: spec.SchemaProps{
+					Properties: map[string]spec.Schema{
+						"method": {
+							SchemaProps: spec.SchemaProps{
+								Description: "HTTP method, defaults to GET",
+								Type:        []string{"string"},
+								Format:      "",
+							},
+						},
+						"scheme": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Scheme to use, HTTP or HTTPS. Defaults to HTTP.",
+								Type:        []string{"string"},
+								Format:      "",
+							},
+						},
+						"host": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Host name to connect to, defaults to localhost as the sidecar shares network with the pod.",
+								Type:        []string{"string"},
+								Format:      "",
+							},
+						},
+						"port": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Port to connect to",
+								Type:        []string{"integer"},
+								Format:      "int32",
+							},
+						},
+						"path": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Path of the HTTP request",
+								Type:        []string{"string"},
+								Format:      "",
+							},
+						},
+						"httpHeaders": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Custom headers to set in the request",
+								Type:        []string{"array"},
+								Items: &spec.SchemaOrArray{
+									Schema: &spec.Schema{
+										SchemaProps: spec.SchemaProps{
+											Ref: ref("k8s.io/api/core/v1.HTTPHeader"),
+										},
+									},
+								},
+							},
+						},
+					},
+					Required: []string{"port"},
+				},
+			},
+			Dependencies: []string{
+				"k8s.io/api/core/v1.HTTPHeader"},
+		},
+		"github.com/appscode/stash/apis/stash/v1alpha1.Hook": {
+			Schema: spec.Schema{
+				SchemaProps: spec.SchemaProps{
+					Description: "Hook is an action executed by Stash in the backed up pod. Exactly one of exec and http must be set.",
+					Properties: map[string]spec.Schema{
+						"name": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Name of the hook, used in events",
+								Type:        []string{"string"},
+								Format:      "",
+							},
+						},
+						"exec": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Exec runs a command in a container of the pod using Kubernetes exec API",
+								Ref:         ref("github.com/appscode/stash/apis/stash/v1alpha1.ExecHook"),
+							},
+						},
+						"http": {
+							SchemaProps: spec.SchemaProps{
+								Description: "HTTP sends a request to the pod",
+								Ref:         ref("github.com/appscode/stash/apis/stash/v1alpha1.HTTPHook"),
+							},
+						},
+						"timeoutSeconds": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Number of seconds after which the hook times out. Defaults to 30 seconds.",
+								Type:        []string{"integer"},
+								Format:      "int32",
+							},
+						},
+						"onFailure": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Whether to Abort or Continue when the hook fails. Defaults to Abort.",
+								Type:        []string{"string"},
+								Format:      "",
+							},
+						},
+					},
+					Required: []string{"name"},
+				},
+			},
+			Dependencies: []string{
+				"github.com/appscode/stash/apis/stash/v1alpha1.ExecHook", "github.com/appscode/stash/apis/stash/v1alpha1.HTTPHook"},
+		},
+		"github.com/appscode/stash/apis/stash/v1alpha1.HookResult": {
+			Schema: spec.Schema{
+				SchemaProps: spec.SchemaProps{
+					Properties: map[string]spec.Schema{
+						"name": {
+							SchemaProps: spec.SchemaProps{
+								Type:   []string{"string"},
+								Format: "",
+							},
+						},
+						"exitCode": {
 							SchemaProps: spec.SchemaProps{
-								Type:   []string{"string"},
-								Format: "",
+								Description: "Exit code of the command, -1 if it did not exit normally",
+								Type:        []string{"integer"},
+								Format:      "int32",
+							},
+						},
+						"output": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Last part of the combined output of the command",
+								Type:        []string{"string"},
+								Format:      "",
+							},
+						},
+						"error": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Reason of failure",
+								Type:        []string{"string"},
+								Format:      "",
 							},
 						},
 					},
+					Required: []string{"exitCode"},
 				},
 			},
 			Dependencies: []string{},
@@ -390,6 +943,66 @@ func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenA
 			},
 			Dependencies: []string{},
 		},
+		"github.com/appscode/stash/apis/stash/v1alpha1.PodExecHook": {
+			Schema: spec.Schema{
+				SchemaProps: spec.SchemaProps{
+					Properties: map[string]spec.Schema{
+						"pod": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Name of the pod where command is executed",
+								Type:        []string{"string"},
+								Format:      "",
+							},
+						},
+						"container": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Name of the container where command is executed",
+								Type:        []string{"string"},
+								Format:      "",
+							},
+						},
+						"command": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Command to execute, it is not run in a shell",
+								Type:        []string{"array"},
+								Items: &spec.SchemaOrArray{
+									Schema: &spec.Schema{
+										SchemaProps: spec.SchemaProps{
+											Type:   []string{"string"},
+											Format: "",
+										},
+									},
+								},
+							},
+						},
+					},
+					Required: []string{"pod", "container", "command"},
+				},
+			},
+			Dependencies: []string{},
+		},
+		"github.com/appscode/stash/apis/stash/v1alpha1.RcloneSpec": {
+			Schema: spec.Schema{
+				SchemaProps: spec.SchemaProps{
+					Properties: map[string]spec.Schema{
+						"remote": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Name of the rclone remote, as configured in rclone.conf",
+								Type:        []string{"string"},
+								Format:      "",
+							},
+						},
+						"path": {
+							SchemaProps: spec.SchemaProps{
+								Type:   []string{"string"},
+								Format: "",
+							},
+						},
+					},
+				},
+			},
+			Dependencies: []string{},
+		},
 		"github.com/appscode/stash/apis/stash/v1alpha1.Recovery": {
 			Schema: spec.Schema{
 				SchemaProps: spec.SchemaProps{
@@ -429,6 +1042,52 @@ func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenA
 			Dependencies: []string{
 				"github.com/appscode/stash/apis/stash/v1alpha1.RecoverySpec", "github.com/appscode/stash/apis/stash/v1alpha1.RecoveryStatus", "k8s.io/apimachinery/pkg/apis/meta/v1.ObjectMeta"},
 		},
+		"github.com/appscode/stash/apis/stash/v1alpha1.RecoveryHook": {
+			Schema: spec.Schema{
+				SchemaProps: spec.SchemaProps{
+					Description: "RecoveryHook is an action executed after restore. Exactly one of command and exec must be set.",
+					Properties: map[string]spec.Schema{
+						"name": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Name of the hook, used in events and status",
+								Type:        []string{"string"},
+								Format:      "",
+							},
+						},
+						"command": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Command executed in the recovery job container, where recovered volumes are mounted. It is not run in a shell.",
+								Type:        []string{"array"},
+								Items: &spec.SchemaOrArray{
+									Schema: &spec.Schema{
+										SchemaProps: spec.SchemaProps{
+											Type:   []string{"string"},
+											Format: "",
+										},
+									},
+								},
+							},
+						},
+						"exec": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Exec runs a command in a container of a pod in the namespace of Recovery using Kubernetes exec API",
+								Ref:         ref("github.com/appscode/stash/apis/stash/v1alpha1.PodExecHook"),
+							},
+						},
+						"timeoutSeconds": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Number of seconds after which the hook times out. Defaults to 30 seconds.",
+								Type:        []string{"integer"},
+								Format:      "int32",
+							},
+						},
+					},
+					Required: []string{"name"},
+				},
+			},
+			Dependencies: []string{
+				"github.com/appscode/stash/apis/stash/v1alpha1.PodExecHook"},
+		},
 		"github.com/appscode/stash/apis/stash/v1alpha1.RecoveryList": {
 			Schema: spec.Schema{
 				SchemaProps: spec.SchemaProps{
@@ -533,11 +1192,24 @@ func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenA
 								},
 							},
 						},
+						"postRestoreHooks": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Hooks executed in order after all paths are restored. Recovery fails if a hook fails.",
+								Type:        []string{"array"},
+								Items: &spec.SchemaOrArray{
+									Schema: &spec.Schema{
+										SchemaProps: spec.SchemaProps{
+											Ref: ref("github.com/appscode/stash/apis/stash/v1alpha1.RecoveryHook"),
+										},
+									},
+								},
+							},
+						},
 					},
 				},
 			},
 			Dependencies: []string{
-				"github.com/appscode/stash/apis/stash/v1alpha1.Backend", "github.com/appscode/stash/apis/stash/v1alpha1.LocalSpec", "github.com/appscode/stash/apis/stash/v1alpha1.LocalTypedReference", "k8s.io/api/core/v1.LocalObjectReference"},
+				"github.com/appscode/stash/apis/stash/v1alpha1.Backend", "github.com/appscode/stash/apis/stash/v1alpha1.LocalSpec", "github.com/appscode/stash/apis/stash/v1alpha1.LocalTypedReference", "github.com/appscode/stash/apis/stash/v1alpha1.RecoveryHook", "k8s.io/api/core/v1.LocalObjectReference"},
 		},
 		"github.com/appscode/stash/apis/stash/v1alpha1.RecoveryStatus": {
 			Schema: spec.Schema{
@@ -561,11 +1233,92 @@ func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenA
 								},
 							},
 						},
+						"progress": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Progress of the running restore, updated periodically",
+								Ref:         ref("github.com/appscode/stash/apis/stash/v1alpha1.ResticProgress"),
+							},
+						},
+						"hookResults": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Results of post restore hooks",
+								Type:        []string{"array"},
+								Items: &spec.SchemaOrArray{
+									Schema: &spec.Schema{
+										SchemaProps: spec.SchemaProps{
+											Ref: ref("github.com/appscode/stash/apis/stash/v1alpha1.HookResult"),
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			Dependencies: []string{
+				"github.com/appscode/stash/apis/stash/v1alpha1.HookResult", "github.com/appscode/stash/apis/stash/v1alpha1.ResticProgress", "github.com/appscode/stash/apis/stash/v1alpha1.RestoreStats"},
+		},
+		"github.com/appscode/stash/apis/stash/v1alpha1.RemovedSnapshot": {
+			Schema: spec.Schema{
+				SchemaProps: spec.SchemaProps{
+					Properties: map[string]spec.Schema{
+						"id": {
+							SchemaProps: spec.SchemaProps{
+								Type:   []string{"string"},
+								Format: "",
+							},
+						},
+						"time": {
+							SchemaProps: spec.SchemaProps{
+								Ref: ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+							},
+						},
+						"hostname": {
+							SchemaProps: spec.SchemaProps{
+								Type:   []string{"string"},
+								Format: "",
+							},
+						},
+						"paths": {
+							SchemaProps: spec.SchemaProps{
+								Type: []string{"array"},
+								Items: &spec.SchemaOrArray{
+									Schema: &spec.Schema{
+										SchemaProps: spec.SchemaProps{
+											Type:   []string{"string"},
+											Format: "",
+										},
+									},
+								},
+							},
+						},
+						"retentionPolicyName": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Name of the retention policy that removed the snapshot",
+								Type:        []string{"string"},
+								Format:      "",
+							},
+						},
+						"dryRun": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Whether the snapshot was kept because the retention policy, or maxRepositorySize of Restic, is applied as dry run",
+								Type:        []string{"boolean"},
+								Format:      "",
+							},
+						},
+						"exceededMaxSize": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Whether the snapshot was removed to fit the repository into maxRepositorySize of Restic",
+								Type:        []string{"boolean"},
+								Format:      "",
+							},
+						},
 					},
+					Required: []string{"id"},
 				},
 			},
 			Dependencies: []string{
-				"github.com/appscode/stash/apis/stash/v1alpha1.RestoreStats"},
+				"k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
 		},
 		"github.com/appscode/stash/apis/stash/v1alpha1.Repository": {
 			Schema: spec.Schema{
@@ -606,6 +1359,49 @@ func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenA
 			Dependencies: []string{
 				"github.com/appscode/stash/apis/stash/v1alpha1.RepositorySpec", "github.com/appscode/stash/apis/stash/v1alpha1.RepositoryStatus", "k8s.io/apimachinery/pkg/apis/meta/v1.ObjectMeta"},
 		},
+		"github.com/appscode/stash/apis/stash/v1alpha1.RepositoryCondition": {
+			Schema: spec.Schema{
+				SchemaProps: spec.SchemaProps{
+					Properties: map[string]spec.Schema{
+						"type": {
+							SchemaProps: spec.SchemaProps{
+								Type:   []string{"string"},
+								Format: "",
+							},
+						},
+						"status": {
+							SchemaProps: spec.SchemaProps{
+								Type:   []string{"string"},
+								Format: "",
+							},
+						},
+						"lastTransitionTime": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Last time the condition changed from one status to another",
+								Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+							},
+						},
+						"reason": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Unique, one-word, CamelCase reason for the condition's last transition",
+								Type:        []string{"string"},
+								Format:      "",
+							},
+						},
+						"message": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Human readable message indicating details about last transition",
+								Type:        []string{"string"},
+								Format:      "",
+							},
+						},
+					},
+					Required: []string{"type", "status"},
+				},
+			},
+			Dependencies: []string{
+				"k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
+		},
 		"github.com/appscode/stash/apis/stash/v1alpha1.RepositoryList": {
 			Schema: spec.Schema{
 				SchemaProps: spec.SchemaProps{
@@ -619,33 +1415,81 @@ func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenA
 						},
 						"apiVersion": {
 							SchemaProps: spec.SchemaProps{
-								Description: "APIVersion defines the versioned schema of this representation of an object. Servers should convert recognized schemas to the latest internal value, and may reject unrecognized values. More info: https://git.k8s.io/community/contributors/devel/api-conventions.md#resources",
-								Type:        []string{"string"},
-								Format:      "",
+								Description: "APIVersion defines the versioned schema of this representation of an object. Servers should convert recognized schemas to the latest internal value, and may reject unrecognized values. More info: https://git.k8s.io/community/contributors/devel/api-conventions.md#resources",
+								Type:        []string{"string"},
+								Format:      "",
+							},
+						},
+						"metadata": {
+							SchemaProps: spec.SchemaProps{
+								Ref: ref("k8s.io/apimachinery/pkg/apis/meta/v1.ListMeta"),
+							},
+						},
+						"items": {
+							SchemaProps: spec.SchemaProps{
+								Type: []string{"array"},
+								Items: &spec.SchemaOrArray{
+									Schema: &spec.Schema{
+										SchemaProps: spec.SchemaProps{
+											Ref: ref("github.com/appscode/stash/apis/stash/v1alpha1.Repository"),
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			Dependencies: []string{
+				"github.com/appscode/stash/apis/stash/v1alpha1.Repository", "k8s.io/apimachinery/pkg/apis/meta/v1.ListMeta"},
+		},
+		"github.com/appscode/stash/apis/stash/v1alpha1.RepositoryMaintenance": {
+			Schema: spec.Schema{
+				SchemaProps: spec.SchemaProps{
+					Description: "RepositoryMaintenance reports a run of the maintenance job of a Repository.",
+					Properties: map[string]spec.Schema{
+						"startTime": {
+							SchemaProps: spec.SchemaProps{
+								Ref: ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
 							},
 						},
-						"metadata": {
+						"completionTime": {
 							SchemaProps: spec.SchemaProps{
-								Ref: ref("k8s.io/apimachinery/pkg/apis/meta/v1.ListMeta"),
+								Ref: ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
 							},
 						},
-						"items": {
+						"removedSnapshots": {
 							SchemaProps: spec.SchemaProps{
-								Type: []string{"array"},
+								Description: "Snapshots removed by retention policies, including snapshots that dry run policies would remove",
+								Type:        []string{"array"},
 								Items: &spec.SchemaOrArray{
 									Schema: &spec.Schema{
 										SchemaProps: spec.SchemaProps{
-											Ref: ref("github.com/appscode/stash/apis/stash/v1alpha1.Repository"),
+											Ref: ref("github.com/appscode/stash/apis/stash/v1alpha1.RemovedSnapshot"),
 										},
 									},
 								},
 							},
 						},
+						"pruned": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Whether data no longer referenced by snapshots was deleted",
+								Type:        []string{"boolean"},
+								Format:      "",
+							},
+						},
+						"error": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Error of the failed run",
+								Type:        []string{"string"},
+								Format:      "",
+							},
+						},
 					},
 				},
 			},
 			Dependencies: []string{
-				"github.com/appscode/stash/apis/stash/v1alpha1.Repository", "k8s.io/apimachinery/pkg/apis/meta/v1.ListMeta"},
+				"github.com/appscode/stash/apis/stash/v1alpha1.RemovedSnapshot", "k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
 		},
 		"github.com/appscode/stash/apis/stash/v1alpha1.RepositorySpec": {
 			Schema: spec.Schema{
@@ -693,11 +1537,49 @@ func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenA
 								Format: "int64",
 							},
 						},
+						"lastFailedBackupTime": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Start time of the last failed backup session",
+								Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+							},
+						},
+						"lastBackupError": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Error of the last failed backup session, removed when a backup session succeeds",
+								Type:        []string{"string"},
+								Format:      "",
+							},
+						},
+						"backupProgress": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Progress of the running backup, updated periodically",
+								Ref:         ref("github.com/appscode/stash/apis/stash/v1alpha1.ResticProgress"),
+							},
+						},
+						"conditions": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Conditions of the Repository, maintained by Stash operator",
+								Type:        []string{"array"},
+								Items: &spec.SchemaOrArray{
+									Schema: &spec.Schema{
+										SchemaProps: spec.SchemaProps{
+											Ref: ref("github.com/appscode/stash/apis/stash/v1alpha1.RepositoryCondition"),
+										},
+									},
+								},
+							},
+						},
+						"lastMaintenance": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Result of the last run of the maintenance job",
+								Ref:         ref("github.com/appscode/stash/apis/stash/v1alpha1.RepositoryMaintenance"),
+							},
+						},
 					},
 				},
 			},
 			Dependencies: []string{
-				"k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
+				"github.com/appscode/stash/apis/stash/v1alpha1.RepositoryCondition", "github.com/appscode/stash/apis/stash/v1alpha1.RepositoryMaintenance", "github.com/appscode/stash/apis/stash/v1alpha1.ResticProgress", "k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
 		},
 		"github.com/appscode/stash/apis/stash/v1alpha1.RestServerSpec": {
 			Schema: spec.Schema{
@@ -789,6 +1671,67 @@ func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenA
 			Dependencies: []string{
 				"github.com/appscode/stash/apis/stash/v1alpha1.Restic", "k8s.io/apimachinery/pkg/apis/meta/v1.ListMeta"},
 		},
+		"github.com/appscode/stash/apis/stash/v1alpha1.ResticProgress": {
+			Schema: spec.Schema{
+				SchemaProps: spec.SchemaProps{
+					Description: "ResticProgress reports progress of a running backup or restore session.",
+					Properties: map[string]spec.Schema{
+						"path": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Path of the FileGroup being processed",
+								Type:        []string{"string"},
+								Format:      "",
+							},
+						},
+						"percentDone": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Percentage of total bytes processed",
+								Type:        []string{"integer"},
+								Format:      "int32",
+							},
+						},
+						"totalBytes": {
+							SchemaProps: spec.SchemaProps{
+								Type:   []string{"integer"},
+								Format: "int64",
+							},
+						},
+						"bytesDone": {
+							SchemaProps: spec.SchemaProps{
+								Type:   []string{"integer"},
+								Format: "int64",
+							},
+						},
+						"totalFiles": {
+							SchemaProps: spec.SchemaProps{
+								Type:   []string{"integer"},
+								Format: "int64",
+							},
+						},
+						"filesDone": {
+							SchemaProps: spec.SchemaProps{
+								Type:   []string{"integer"},
+								Format: "int64",
+							},
+						},
+						"eta": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Estimated time to finish the FileGroup",
+								Type:        []string{"string"},
+								Format:      "",
+							},
+						},
+						"lastUpdateTime": {
+							SchemaProps: spec.SchemaProps{
+								Ref: ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+							},
+						},
+					},
+				},
+			},
+			Dependencies: []string{
+				"k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
+		},
 		"github.com/appscode/stash/apis/stash/v1alpha1.ResticSpec": {
 			Schema: spec.Schema{
 				SchemaProps: spec.SchemaProps{
@@ -879,11 +1822,90 @@ func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenA
 								},
 							},
 						},
+						"hooks": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Hooks executed by the sidecar around each backup session",
+								Ref:         ref("github.com/appscode/stash/apis/stash/v1alpha1.BackupHooks"),
+							},
+						},
+						"backupHistoryLimit": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Number of BackupSessions kept for each Repository. Defaults to 10, 0 disables BackupSessions.",
+								Type:        []string{"integer"},
+								Format:      "int32",
+							},
+						},
+						"executionPolicy": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Timeout, retries and failure handling of backup",
+								Ref:         ref("github.com/appscode/stash/apis/stash/v1alpha1.ExecutionPolicy"),
+							},
+						},
+						"missedRunPolicy": {
+							SchemaProps: spec.SchemaProps{
+								Description: "What to do when a scheduled backup is due while another backup is running. Defaults to Skip.",
+								Type:        []string{"string"},
+								Format:      "",
+							},
+						},
+						"backupOnStart": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Run backup as soon as the sidecar starts if the repository has no snapshot of this host yet",
+								Type:        []string{"boolean"},
+								Format:      "",
+							},
+						},
+						"timeZone": {
+							SchemaProps: spec.SchemaProps{
+								Description: "IANA time zone of schedule and blackout windows, eg: Europe/Berlin. Defaults to the time zone of the sidecar, usually UTC.",
+								Type:        []string{"string"},
+								Format:      "",
+							},
+						},
+						"blackoutWindows": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Time ranges when scheduled backups are skipped",
+								Type:        []string{"array"},
+								Items: &spec.SchemaOrArray{
+									Schema: &spec.Schema{
+										SchemaProps: spec.SchemaProps{
+											Ref: ref("github.com/appscode/stash/apis/stash/v1alpha1.BlackoutWindow"),
+										},
+									},
+								},
+							},
+						},
+						"jitter": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Window to spread scheduled backups of sidecars in. Each sidecar delays its scheduled backups by a stable offset within the window, derived from its hostname.",
+								Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Duration"),
+							},
+						},
+						"scheduler": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Whether scheduled backups are run by each sidecar or dispatched by operator. Defaults to Sidecar.",
+								Type:        []string{"string"},
+								Format:      "",
+							},
+						},
+						"maintenanceSchedule": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Cron schedule of the maintenance job that applies retention policies to each Repository of this Restic. Defaults to @daily.",
+								Type:        []string{"string"},
+								Format:      "",
+							},
+						},
+						"maxRepositorySize": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Maximum size of each Repository of this Restic. The maintenance job removes the oldest snapshots not kept by retention policies until the repository fits into this size.",
+								Ref:         ref("k8s.io/apimachinery/pkg/api/resource.Quantity"),
+							},
+						},
 					},
 				},
 			},
 			Dependencies: []string{
-				"github.com/appscode/stash/apis/stash/v1alpha1.Backend", "github.com/appscode/stash/apis/stash/v1alpha1.FileGroup", "github.com/appscode/stash/apis/stash/v1alpha1.RetentionPolicy", "k8s.io/api/core/v1.LocalObjectReference", "k8s.io/api/core/v1.ResourceRequirements", "k8s.io/api/core/v1.VolumeMount", "k8s.io/apimachinery/pkg/apis/meta/v1.LabelSelector"},
+				"github.com/appscode/stash/apis/stash/v1alpha1.Backend", "github.com/appscode/stash/apis/stash/v1alpha1.BackupHooks", "github.com/appscode/stash/apis/stash/v1alpha1.BlackoutWindow", "github.com/appscode/stash/apis/stash/v1alpha1.ExecutionPolicy", "github.com/appscode/stash/apis/stash/v1alpha1.FileGroup", "github.com/appscode/stash/apis/stash/v1alpha1.RetentionPolicy", "k8s.io/api/core/v1.LocalObjectReference", "k8s.io/api/core/v1.ResourceRequirements", "k8s.io/api/core/v1.VolumeMount", "k8s.io/apimachinery/pkg/api/resource.Quantity", "k8s.io/apimachinery/pkg/apis/meta/v1.Duration", "k8s.io/apimachinery/pkg/apis/meta/v1.LabelSelector"},
 		},
 		"github.com/appscode/stash/apis/stash/v1alpha1.RestoreStats": {
 			Schema: spec.Schema{
@@ -971,6 +1993,62 @@ func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenA
 								},
 							},
 						},
+						"keepWithin": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Keep all snapshots taken within this duration of the latest snapshot, eg: 14d or 1y6m. Durations are a sequence of numbers with units y, m, d and h.",
+								Type:        []string{"string"},
+								Format:      "",
+							},
+						},
+						"keepWithinHourly": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Keep the last snapshot of each hour within this duration of the latest snapshot",
+								Type:        []string{"string"},
+								Format:      "",
+							},
+						},
+						"keepWithinDaily": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Keep the last snapshot of each day within this duration of the latest snapshot",
+								Type:        []string{"string"},
+								Format:      "",
+							},
+						},
+						"keepWithinWeekly": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Keep the last snapshot of each week within this duration of the latest snapshot",
+								Type:        []string{"string"},
+								Format:      "",
+							},
+						},
+						"keepWithinMonthly": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Keep the last snapshot of each month within this duration of the latest snapshot",
+								Type:        []string{"string"},
+								Format:      "",
+							},
+						},
+						"keepWithinYearly": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Keep the last snapshot of each year within this duration of the latest snapshot",
+								Type:        []string{"string"},
+								Format:      "",
+							},
+						},
+						"groupBy": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Snapshots are grouped by these fields and the policy is applied to each group separately. Defaults to host and paths.",
+								Type:        []string{"array"},
+								Items: &spec.SchemaOrArray{
+									Schema: &spec.Schema{
+										SchemaProps: spec.SchemaProps{
+											Type:   []string{"string"},
+											Format: "",
+										},
+									},
+								},
+							},
+						},
 						"prune": {
 							SchemaProps: spec.SchemaProps{
 								Type:   []string{"boolean"},
@@ -1010,6 +2088,81 @@ func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenA
 								Format: "",
 							},
 						},
+						"region": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Region of the bucket",
+								Type:        []string{"string"},
+								Format:      "",
+							},
+						},
+						"bucketLookup": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Bucket lookup style used to access the bucket. Default value is 'auto'",
+								Type:        []string{"string"},
+								Format:      "",
+							},
+						},
+						"storageClass": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Storage class for newly created objects, e.g. STANDARD_IA",
+								Type:        []string{"string"},
+								Format:      "",
+							},
+						},
+						"insecureSkipTLSVerify": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Skip TLS certificate verification of the S3 endpoint. Do not use it in production.",
+								Type:        []string{"boolean"},
+								Format:      "",
+							},
+						},
+					},
+				},
+			},
+			Dependencies: []string{},
+		},
+		"github.com/appscode/stash/apis/stash/v1alpha1.SFTPSpec": {
+			Schema: spec.Schema{
+				SchemaProps: spec.SchemaProps{
+					Properties: map[string]spec.Schema{
+						"host": {
+							SchemaProps: spec.SchemaProps{
+								Type:   []string{"string"},
+								Format: "",
+							},
+						},
+						"port": {
+							SchemaProps: spec.SchemaProps{
+								Type:   []string{"integer"},
+								Format: "int32",
+							},
+						},
+						"user": {
+							SchemaProps: spec.SchemaProps{
+								Type:   []string{"string"},
+								Format: "",
+							},
+						},
+						"path": {
+							SchemaProps: spec.SchemaProps{
+								Type:   []string{"string"},
+								Format: "",
+							},
+						},
+						"knownHosts": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Public keys of the SFTP server in known_hosts format. Required unless InsecureSkipHostKeyVerification is set.",
+								Type:        []string{"string"},
+								Format:      "",
+							},
+						},
+						"insecureSkipHostKeyVerification": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Skip host key verification of the SFTP server if KnownHosts is empty. Do not use it in production.",
+								Type:        []string{"boolean"},
+								Format:      "",
+							},
+						},
 					},
 				},
 			},
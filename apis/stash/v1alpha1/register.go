@@ -38,6 +38,8 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 		&RecoveryList{},
 		&Repository{},
 		&RepositoryList{},
+		&BackupSession{},
+		&BackupSessionList{},
 	)
 
 	scheme.AddKnownTypes(SchemeGroupVersion,
@@ -2,6 +2,7 @@ package v1alpha1
 
 import (
 	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -46,8 +47,103 @@ type ResticSpec struct {
 	// More info: https://kubernetes.io/docs/concepts/containers/images#specifying-imagepullsecrets-on-a-pod
 	// +optional
 	ImagePullSecrets []core.LocalObjectReference `json:"imagePullSecrets,omitempty"`
+	// Hooks executed by the sidecar around each backup session
+	// +optional
+	Hooks *BackupHooks `json:"hooks,omitempty"`
+	// Number of BackupSessions kept for each Repository. Defaults to 10, 0 disables BackupSessions.
+	// +optional
+	BackupHistoryLimit *int32 `json:"backupHistoryLimit,omitempty"`
+	// Timeout, retries and failure handling of backup
+	// +optional
+	ExecutionPolicy *ExecutionPolicy `json:"executionPolicy,omitempty"`
+	// What to do when a scheduled backup is due while another backup is running. Defaults to Skip.
+	// +optional
+	MissedRunPolicy MissedRunPolicy `json:"missedRunPolicy,omitempty"`
+	// Run backup as soon as the sidecar starts if the repository has no snapshot of this host yet
+	// +optional
+	BackupOnStart bool `json:"backupOnStart,omitempty"`
+	// IANA time zone of schedule and blackout windows, eg: Europe/Berlin. Defaults to the time zone of the sidecar, usually UTC.
+	// +optional
+	TimeZone string `json:"timeZone,omitempty"`
+	// Time ranges when scheduled backups are skipped
+	// +optional
+	BlackoutWindows []BlackoutWindow `json:"blackoutWindows,omitempty"`
+	// Window to spread scheduled backups of sidecars in. Each sidecar delays its scheduled backups
+	// by a stable offset within the window, derived from its hostname.
+	// +optional
+	Jitter *metav1.Duration `json:"jitter,omitempty"`
+	// Whether scheduled backups are run by each sidecar or dispatched by operator. Defaults to Sidecar.
+	// +optional
+	Scheduler SchedulerType `json:"scheduler,omitempty"`
+	// Cron schedule of the maintenance job that applies retention policies to each Repository of this Restic.
+	// Defaults to @daily.
+	// +optional
+	MaintenanceSchedule string `json:"maintenanceSchedule,omitempty"`
+	// Maximum size of each Repository of this Restic. The maintenance job removes the oldest snapshots
+	// not kept by retention policies until the repository fits into this size.
+	// +optional
+	MaxRepositorySize *resource.Quantity `json:"maxRepositorySize,omitempty"`
+}
+
+type SchedulerType string
+
+const (
+	// Each sidecar runs its own cron
+	SchedulerSidecar SchedulerType = "Sidecar"
+	// Operator dispatches due backups to sidecars, within concurrency limits of operator
+	SchedulerOperator SchedulerType = "Operator"
+)
+
+type BlackoutWindow struct {
+	// Days of week when the window starts, eg: Monday or Mon. Every day if empty.
+	// +optional
+	Days []string `json:"days,omitempty"`
+	// Start of the window in HH:MM format
+	Start string `json:"start"`
+	// End of the window in HH:MM format. A window ending at or before its start ends on the next day.
+	End string `json:"end"`
+}
+
+type MissedRunPolicy string
+
+const (
+	// Missed runs are skipped
+	MissedRunPolicySkip MissedRunPolicy = "Skip"
+	// A single backup runs after the running backup completes, no matter how many runs were missed
+	MissedRunPolicyQueueOne MissedRunPolicy = "QueueOne"
+	// Every missed run is run one after another after the running backup completes
+	MissedRunPolicyCatchUp MissedRunPolicy = "CatchUp"
+)
+
+type ExecutionPolicy struct {
+	// Maximum duration to backup a FileGroup, including all retries and waiting for the repository lock.
+	// restic is killed when exceeded. Does not apply to the maintenance job. No limit by default.
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+	// Number of times backup of a FileGroup is retried after a transient failure,
+	// ie: unreachable backend or timeout. Backup waits for a locked repository without counting attempts.
+	// +optional
+	Retries int32 `json:"retries,omitempty"`
+	// Delay before the first retry, doubled for each later retry. Defaults to 30s.
+	// +optional
+	Backoff *metav1.Duration `json:"backoff,omitempty"`
+	// Whether remaining FileGroups are backed up when backup of a FileGroup fails. Defaults to Abort.
+	// +optional
+	OnFileGroupFailure FileGroupFailurePolicy `json:"onFileGroupFailure,omitempty"`
+	// Locks of the repository not refreshed by restic for this duration are removed when they block
+	// backup, check or maintenance, unless their host is a running pod of the workload. Locks of restic
+	// processes of the same pod that no longer run are always removed. Defaults to and must be at least 10m.
+	// +optional
+	StaleLockTimeout *metav1.Duration `json:"staleLockTimeout,omitempty"`
 }
 
+type FileGroupFailurePolicy string
+
+const (
+	FileGroupFailurePolicyAbort    FileGroupFailurePolicy = "Abort"
+	FileGroupFailurePolicyContinue FileGroupFailurePolicy = "Continue"
+)
+
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
 type ResticList struct {
@@ -65,16 +161,79 @@ type FileGroup struct {
 	RetentionPolicyName string `json:"retentionPolicyName,omitempty"`
 }
 
+type BackupHooks struct {
+	// Hooks executed before backup. Backup is skipped if a hook with Abort failure policy fails.
+	// +optional
+	PreBackup []Hook `json:"preBackup,omitempty"`
+	// Hooks executed after backup, even if backup or a pre backup hook failed.
+	// +optional
+	PostBackup []Hook `json:"postBackup,omitempty"`
+}
+
+// Hook is an action executed by Stash in the backed up pod. Exactly one of exec and http must be set.
+type Hook struct {
+	// Name of the hook, used in events
+	Name string `json:"name"`
+	// Exec runs a command in a container of the pod using Kubernetes exec API
+	// +optional
+	Exec *ExecHook `json:"exec,omitempty"`
+	// HTTP sends a request to the pod
+	// +optional
+	HTTP *HTTPHook `json:"http,omitempty"`
+	// Number of seconds after which the hook times out. Defaults to 30 seconds.
+	// +optional
+	TimeoutSeconds int32 `json:"timeoutSeconds,omitempty"`
+	// Whether to Abort or Continue when the hook fails. Defaults to Abort.
+	// +optional
+	OnFailure HookFailurePolicy `json:"onFailure,omitempty"`
+}
+
+type HookFailurePolicy string
+
+const (
+	HookFailurePolicyAbort    HookFailurePolicy = "Abort"
+	HookFailurePolicyContinue HookFailurePolicy = "Continue"
+)
+
+type ExecHook struct {
+	// Name of the container where command is executed
+	Container string `json:"container"`
+	// Command to execute, it is not run in a shell
+	Command []string `json:"command"`
+}
+
+type HTTPHook struct {
+	// HTTP method, defaults to GET
+	// +optional
+	Method string `json:"method,omitempty"`
+	// Scheme to use, HTTP or HTTPS. Defaults to HTTP.
+	// +optional
+	Scheme core.URIScheme `json:"scheme,omitempty"`
+	// Host name to connect to, defaults to localhost as the sidecar shares network with the pod.
+	// +optional
+	Host string `json:"host,omitempty"`
+	// Port to connect to
+	Port int32 `json:"port"`
+	// Path of the HTTP request
+	// +optional
+	Path string `json:"path,omitempty"`
+	// Custom headers to set in the request
+	// +optional
+	HTTPHeaders []core.HTTPHeader `json:"httpHeaders,omitempty"`
+}
+
 type Backend struct {
 	StorageSecretName string `json:"storageSecretName,omitempty"`
 
-	Local *LocalSpec `json:"local,omitempty"`
-	S3    *S3Spec    `json:"s3,omitempty"`
-	GCS   *GCSSpec   `json:"gcs,omitempty"`
-	Azure *AzureSpec `json:"azure,omitempty"`
-	Swift *SwiftSpec `json:"swift,omitempty"`
-	B2    *B2Spec    `json:"b2,omitempty"`
-	// Rest  *RestServerSpec `json:"rest,omitempty"`
+	Local  *LocalSpec      `json:"local,omitempty"`
+	S3     *S3Spec         `json:"s3,omitempty"`
+	GCS    *GCSSpec        `json:"gcs,omitempty"`
+	Azure  *AzureSpec      `json:"azure,omitempty"`
+	Swift  *SwiftSpec      `json:"swift,omitempty"`
+	B2     *B2Spec         `json:"b2,omitempty"`
+	Rest   *RestServerSpec `json:"rest,omitempty"`
+	SFTP   *SFTPSpec       `json:"sftp,omitempty"`
+	Rclone *RcloneSpec     `json:"rclone,omitempty"`
 }
 
 type LocalSpec struct {
@@ -87,8 +246,28 @@ type S3Spec struct {
 	Endpoint string `json:"endpoint,omitempty"`
 	Bucket   string `json:"bucket,omitempty"`
 	Prefix   string `json:"prefix,omitempty"`
+	// Region of the bucket
+	// +optional
+	Region string `json:"region,omitempty"`
+	// Bucket lookup style used to access the bucket. Default value is 'auto'
+	// +optional
+	BucketLookup S3BucketLookup `json:"bucketLookup,omitempty"`
+	// Storage class for newly created objects, e.g. STANDARD_IA
+	// +optional
+	StorageClass string `json:"storageClass,omitempty"`
+	// Skip TLS certificate verification of the S3 endpoint. Do not use it in production.
+	// +optional
+	InsecureSkipTLSVerify bool `json:"insecureSkipTLSVerify,omitempty"`
 }
 
+type S3BucketLookup string
+
+const (
+	S3BucketLookupAuto S3BucketLookup = "auto"
+	S3BucketLookupDNS  S3BucketLookup = "dns"  // virtual-hosted style
+	S3BucketLookupPath S3BucketLookup = "path" // path style
+)
+
 type GCSSpec struct {
 	Bucket string `json:"bucket,omitempty"`
 	Prefix string `json:"prefix,omitempty"`
@@ -97,6 +276,16 @@ type GCSSpec struct {
 type AzureSpec struct {
 	Container string `json:"container,omitempty"`
 	Prefix    string `json:"prefix,omitempty"`
+	// Endpoint suffix of the storage service. Default value is 'core.windows.net' (public Azure cloud).
+	// Use 'core.usgovcloudapi.net' for Azure Government, 'core.chinacloudapi.cn' for Azure China
+	// or the host of a local emulator like Azurite.
+	// +optional
+	EndpointSuffix string `json:"endpointSuffix,omitempty"`
+	// Endpoint of the blob service of the storage account, eg: 'https://<account>.blob.core.windows.net'.
+	// restic only accesses https://<account>.blob.<suffix>, so endpoints with the account in the path,
+	// like 'http://azurite:10000/devstoreaccount1' of Azurite, are not supported. Conflicts with EndpointSuffix.
+	// +optional
+	Endpoint string `json:"endpoint,omitempty"`
 }
 
 type SwiftSpec struct {
@@ -113,6 +302,25 @@ type RestServerSpec struct {
 	URL string `json:"url,omitempty"`
 }
 
+type SFTPSpec struct {
+	Host string `json:"host,omitempty"`
+	Port int32  `json:"port,omitempty"`
+	User string `json:"user,omitempty"`
+	Path string `json:"path,omitempty"`
+	// Public keys of the SFTP server in known_hosts format.
+	// Required unless InsecureSkipHostKeyVerification is set.
+	KnownHosts string `json:"knownHosts,omitempty"`
+	// Skip host key verification of the SFTP server if KnownHosts is empty. Do not use it in production.
+	// +optional
+	InsecureSkipHostKeyVerification bool `json:"insecureSkipHostKeyVerification,omitempty"`
+}
+
+type RcloneSpec struct {
+	// Name of the rclone remote, as configured in rclone.conf
+	Remote string `json:"remote,omitempty"`
+	Path   string `json:"path,omitempty"`
+}
+
 type BackupType string
 
 const (
@@ -130,6 +338,13 @@ const (
 	KeepMonthly RetentionStrategy = "--keep-monthly"
 	KeepYearly  RetentionStrategy = "--keep-yearly"
 	KeepTag     RetentionStrategy = "--keep-tag"
+
+	KeepWithin        RetentionStrategy = "--keep-within"
+	KeepWithinHourly  RetentionStrategy = "--keep-within-hourly"
+	KeepWithinDaily   RetentionStrategy = "--keep-within-daily"
+	KeepWithinWeekly  RetentionStrategy = "--keep-within-weekly"
+	KeepWithinMonthly RetentionStrategy = "--keep-within-monthly"
+	KeepWithinYearly  RetentionStrategy = "--keep-within-yearly"
 )
 
 type RetentionPolicy struct {
@@ -141,10 +356,41 @@ type RetentionPolicy struct {
 	KeepMonthly int      `json:"keepMonthly,omitempty"`
 	KeepYearly  int      `json:"keepYearly,omitempty"`
 	KeepTags    []string `json:"keepTags,omitempty"`
-	Prune       bool     `json:"prune,omitempty"`
-	DryRun      bool     `json:"dryRun,omitempty"`
+	// Keep all snapshots taken within this duration of the latest snapshot, eg: 14d or 1y6m.
+	// Durations are a sequence of numbers with units y, m, d and h.
+	// +optional
+	KeepWithin string `json:"keepWithin,omitempty"`
+	// Keep the last snapshot of each hour within this duration of the latest snapshot
+	// +optional
+	KeepWithinHourly string `json:"keepWithinHourly,omitempty"`
+	// Keep the last snapshot of each day within this duration of the latest snapshot
+	// +optional
+	KeepWithinDaily string `json:"keepWithinDaily,omitempty"`
+	// Keep the last snapshot of each week within this duration of the latest snapshot
+	// +optional
+	KeepWithinWeekly string `json:"keepWithinWeekly,omitempty"`
+	// Keep the last snapshot of each month within this duration of the latest snapshot
+	// +optional
+	KeepWithinMonthly string `json:"keepWithinMonthly,omitempty"`
+	// Keep the last snapshot of each year within this duration of the latest snapshot
+	// +optional
+	KeepWithinYearly string `json:"keepWithinYearly,omitempty"`
+	// Snapshots are grouped by these fields and the policy is applied to each group separately.
+	// Defaults to host and paths.
+	// +optional
+	GroupBy []SnapshotGroupKey `json:"groupBy,omitempty"`
+	Prune   bool               `json:"prune,omitempty"`
+	DryRun  bool               `json:"dryRun,omitempty"`
 }
 
+type SnapshotGroupKey string
+
+const (
+	GroupByHost  SnapshotGroupKey = "host"
+	GroupByPaths SnapshotGroupKey = "paths"
+	GroupByTags  SnapshotGroupKey = "tags"
+)
+
 // +genclient
 // +genclient:skipVerbs=updateStatus
 // +k8s:openapi-gen=true
@@ -165,6 +411,31 @@ type RecoverySpec struct {
 	NodeName         string                      `json:"nodeName,omitempty"`
 	RecoveredVolumes []LocalSpec                 `json:"recoveredVolumes,omitempty"`
 	ImagePullSecrets []core.LocalObjectReference `json:"imagePullSecrets,omitempty"`
+	// Hooks executed in order after all paths are restored. Recovery fails if a hook fails.
+	// +optional
+	PostRestoreHooks []RecoveryHook `json:"postRestoreHooks,omitempty"`
+}
+
+// RecoveryHook is an action executed after restore. Exactly one of command and exec must be set.
+type RecoveryHook struct {
+	// Name of the hook, used in events and status
+	Name string `json:"name"`
+	// Command executed in the recovery job container, where recovered volumes are mounted.
+	// It is not run in a shell.
+	// +optional
+	Command []string `json:"command,omitempty"`
+	// Exec runs a command in a container of a pod in the namespace of Recovery using Kubernetes exec API
+	// +optional
+	Exec *PodExecHook `json:"exec,omitempty"`
+	// Number of seconds after which the hook times out. Defaults to 30 seconds.
+	// +optional
+	TimeoutSeconds int32 `json:"timeoutSeconds,omitempty"`
+}
+
+type PodExecHook struct {
+	// Name of the pod where command is executed
+	Pod      string `json:"pod"`
+	ExecHook `json:",inline"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -203,6 +474,24 @@ const (
 type RecoveryStatus struct {
 	Phase RecoveryPhase  `json:"phase,omitempty"`
 	Stats []RestoreStats `json:"stats,omitempty"`
+	// Progress of the running restore, updated periodically
+	// +optional
+	Progress *ResticProgress `json:"progress,omitempty"`
+	// Results of post restore hooks
+	// +optional
+	HookResults []HookResult `json:"hookResults,omitempty"`
+}
+
+type HookResult struct {
+	Name string `json:"name,omitempty"`
+	// Exit code of the command, -1 if it did not exit normally
+	ExitCode int32 `json:"exitCode"`
+	// Last part of the combined output of the command
+	// +optional
+	Output string `json:"output,omitempty"`
+	// Reason of failure
+	// +optional
+	Error string `json:"error,omitempty"`
 }
 
 type RestoreStats struct {
@@ -239,6 +528,79 @@ type RepositoryStatus struct {
 	LastSuccessfulBackupTime *metav1.Time `json:"lastSuccessfulBackupTime,omitempty"`
 	LastBackupDuration       string       `json:"lastBackupDuration,omitempty"`
 	BackupCount              int64        `json:"backupCount,omitempty"`
+	// Start time of the last failed backup session
+	// +optional
+	LastFailedBackupTime *metav1.Time `json:"lastFailedBackupTime,omitempty"`
+	// Error of the last failed backup session, removed when a backup session succeeds
+	// +optional
+	LastBackupError string `json:"lastBackupError,omitempty"`
+	// Progress of the running backup, updated periodically
+	// +optional
+	BackupProgress *ResticProgress `json:"backupProgress,omitempty"`
+	// Conditions of the Repository, maintained by Stash operator
+	// +optional
+	Conditions []RepositoryCondition `json:"conditions,omitempty"`
+	// Result of the last run of the maintenance job
+	// +optional
+	LastMaintenance *RepositoryMaintenance `json:"lastMaintenance,omitempty"`
+}
+
+// RepositoryMaintenance reports a run of the maintenance job of a Repository.
+type RepositoryMaintenance struct {
+	StartTime      *metav1.Time `json:"startTime,omitempty"`
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+	// Snapshots removed by retention policies, including snapshots that dry run policies would remove
+	RemovedSnapshots []RemovedSnapshot `json:"removedSnapshots,omitempty"`
+	// Whether data no longer referenced by snapshots was deleted
+	Pruned bool `json:"pruned,omitempty"`
+	// Error of the failed run
+	Error string `json:"error,omitempty"`
+}
+
+type RemovedSnapshot struct {
+	ID       string      `json:"id"`
+	Time     metav1.Time `json:"time,omitempty"`
+	Hostname string      `json:"hostname,omitempty"`
+	Paths    []string    `json:"paths,omitempty"`
+	// Name of the retention policy that removed the snapshot
+	RetentionPolicyName string `json:"retentionPolicyName,omitempty"`
+	// Whether the snapshot was kept because the retention policy, or maxRepositorySize of Restic, is applied as dry run
+	DryRun bool `json:"dryRun,omitempty"`
+	// Whether the snapshot was removed to fit the repository into maxRepositorySize of Restic
+	ExceededMaxSize bool `json:"exceededMaxSize,omitempty"`
+}
+
+type RepositoryConditionType string
+
+const (
+	// No backup succeeded within the interval expected from the schedule of Restic
+	RepositoryBackupOverdue RepositoryConditionType = "BackupOverdue"
+)
+
+type RepositoryCondition struct {
+	Type   RepositoryConditionType `json:"type"`
+	Status core.ConditionStatus    `json:"status"`
+	// Last time the condition changed from one status to another
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+	// Unique, one-word, CamelCase reason for the condition's last transition
+	Reason string `json:"reason,omitempty"`
+	// Human readable message indicating details about last transition
+	Message string `json:"message,omitempty"`
+}
+
+// ResticProgress reports progress of a running backup or restore session.
+type ResticProgress struct {
+	// Path of the FileGroup being processed
+	Path string `json:"path,omitempty"`
+	// Percentage of total bytes processed
+	PercentDone int32 `json:"percentDone,omitempty"`
+	TotalBytes  int64 `json:"totalBytes,omitempty"`
+	BytesDone   int64 `json:"bytesDone,omitempty"`
+	TotalFiles  int64 `json:"totalFiles,omitempty"`
+	FilesDone   int64 `json:"filesDone,omitempty"`
+	// Estimated time to finish the FileGroup
+	ETA            string       `json:"eta,omitempty"`
+	LastUpdateTime *metav1.Time `json:"lastUpdateTime,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -248,3 +610,77 @@ type RepositoryList struct {
 	metav1.ListMeta `json:"metadata,omitempty"`
 	Items           []Repository `json:"items,omitempty"`
 }
+
+const (
+	ResourceKindBackupSession     = "BackupSession"
+	ResourcePluralBackupSession   = "backupsessions"
+	ResourceSingularBackupSession = "backupsession"
+)
+
+// +genclient
+// +genclient:skipVerbs=updateStatus
+// +k8s:openapi-gen=true
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// BackupSession records a run of backup by a stash sidecar.
+type BackupSession struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              BackupSessionSpec   `json:"spec,omitempty"`
+	Status            BackupSessionStatus `json:"status,omitempty"`
+}
+
+type BackupSessionSpec struct {
+	// Name of the Restic used for backup
+	Restic string `json:"restic,omitempty"`
+	// Name of the Repository where snapshots are stored
+	Repository string `json:"repository,omitempty"`
+	// Workload backed up in this session
+	Workload LocalTypedReference `json:"workload,omitempty"`
+	// Pod that ran the backup
+	PodName string `json:"podName,omitempty"`
+	// Node where the pod was running
+	NodeName string `json:"nodeName,omitempty"`
+	// Hostname stored in snapshots
+	Hostname string `json:"hostname,omitempty"`
+}
+
+type BackupSessionPhase string
+
+const (
+	BackupSessionRunning   BackupSessionPhase = "Running"
+	BackupSessionSucceeded BackupSessionPhase = "Succeeded"
+	BackupSessionFailed    BackupSessionPhase = "Failed"
+)
+
+type BackupSessionStatus struct {
+	Phase          BackupSessionPhase `json:"phase,omitempty"`
+	StartTime      *metav1.Time       `json:"startTime,omitempty"`
+	CompletionTime *metav1.Time       `json:"completionTime,omitempty"`
+	// Results of the FileGroups processed in this session
+	// +optional
+	FileGroups []FileGroupStats `json:"fileGroups,omitempty"`
+	// Reason of failure
+	// +optional
+	Error string `json:"error,omitempty"`
+}
+
+type FileGroupStats struct {
+	Path string `json:"path,omitempty"`
+	// ID of the snapshot taken for this FileGroup
+	SnapshotID          string `json:"snapshotID,omitempty"`
+	FilesNew            int64  `json:"filesNew,omitempty"`
+	FilesChanged        int64  `json:"filesChanged,omitempty"`
+	FilesUnmodified     int64  `json:"filesUnmodified,omitempty"`
+	DataAdded           int64  `json:"dataAdded,omitempty"`
+	TotalBytesProcessed int64  `json:"totalBytesProcessed,omitempty"`
+	Duration            string `json:"duration,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+type BackupSessionList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []BackupSession `json:"items,omitempty"`
+}
@@ -2,10 +2,16 @@ package v1alpha1
 
 import (
 	"fmt"
+	"regexp"
+	"time"
 
 	"gopkg.in/robfig/cron.v2"
 )
 
+// restic refreshes its locks every 5 minutes, so a lock not refreshed for twice as long is left by a
+// process that no longer runs.
+const MinStaleLockTimeout = 10 * time.Minute
+
 func (r Restic) IsValid() error {
 	for i, fg := range r.Spec.FileGroups {
 		if fg.RetentionPolicyName == "" {
@@ -24,13 +30,166 @@ func (r Restic) IsValid() error {
 		}
 	}
 
-	_, err := cron.Parse(r.Spec.Schedule)
+	for i, policy := range r.Spec.RetentionPolicies {
+		if err := policy.IsValid(); err != nil {
+			return fmt.Errorf("spec.retentionPolicies[%d] is invalid. Reason: %s", i, err)
+		}
+	}
+
+	if _, err := r.Spec.Location(); err != nil {
+		return fmt.Errorf("spec.timeZone %s is invalid. Reason: %s", r.Spec.TimeZone, err)
+	}
+	_, err := cron.Parse(r.Spec.CronSchedule())
 	if err != nil {
 		return fmt.Errorf("spec.schedule %s is invalid. Reason: %s", r.Spec.Schedule, err)
 	}
+	if r.Spec.MaxRepositorySize != nil && r.Spec.MaxRepositorySize.Sign() <= 0 {
+		return fmt.Errorf("spec.maxRepositorySize %s is not positive", r.Spec.MaxRepositorySize.String())
+	}
+	if r.Spec.MaintenanceSchedule != "" {
+		if _, err := cron.Parse(r.Spec.MaintenanceSchedule); err != nil {
+			return fmt.Errorf("spec.maintenanceSchedule %s is invalid. Reason: %s", r.Spec.MaintenanceSchedule, err)
+		}
+	}
+	for i, w := range r.Spec.BlackoutWindows {
+		if _, err := w.Contains(time.Now()); err != nil {
+			return fmt.Errorf("spec.blackoutWindows[%d] is invalid. Reason: %s", i, err)
+		}
+		for _, d := range w.Days {
+			if _, ok := parseWeekday(d); !ok {
+				return fmt.Errorf("spec.blackoutWindows[%d] has invalid day %s", i, d)
+			}
+		}
+	}
 	if r.Spec.Backend.StorageSecretName == "" {
 		return fmt.Errorf("missing repository secret name")
 	}
+	if r.Spec.Hooks != nil {
+		for i, hook := range r.Spec.Hooks.PreBackup {
+			if err := hook.IsValid(); err != nil {
+				return fmt.Errorf("spec.hooks.preBackup[%d] is invalid. Reason: %s", i, err)
+			}
+		}
+		for i, hook := range r.Spec.Hooks.PostBackup {
+			if err := hook.IsValid(); err != nil {
+				return fmt.Errorf("spec.hooks.postBackup[%d] is invalid. Reason: %s", i, err)
+			}
+		}
+	}
+	if r.Spec.Jitter != nil && r.Spec.Jitter.Duration < 0 {
+		return fmt.Errorf("spec.jitter %s is negative", r.Spec.Jitter.Duration)
+	}
+	if p := r.Spec.ExecutionPolicy; p != nil {
+		if p.Timeout != nil && p.Timeout.Duration < 0 {
+			return fmt.Errorf("spec.executionPolicy.timeout %s is negative", p.Timeout.Duration)
+		}
+		if p.Retries < 0 {
+			return fmt.Errorf("spec.executionPolicy.retries %d is negative", p.Retries)
+		}
+		if p.Backoff != nil && p.Backoff.Duration < 0 {
+			return fmt.Errorf("spec.executionPolicy.backoff %s is negative", p.Backoff.Duration)
+		}
+		if p.StaleLockTimeout != nil && p.StaleLockTimeout.Duration > 0 && p.StaleLockTimeout.Duration < MinStaleLockTimeout {
+			return fmt.Errorf("spec.executionPolicy.staleLockTimeout %s is less than %s", p.StaleLockTimeout.Duration, MinStaleLockTimeout)
+		}
+		switch p.OnFileGroupFailure {
+		case "", FileGroupFailurePolicyAbort, FileGroupFailurePolicyContinue:
+		default:
+			return fmt.Errorf("spec.executionPolicy.onFileGroupFailure %s is invalid", p.OnFileGroupFailure)
+		}
+	}
+	switch r.Spec.Scheduler {
+	case "", SchedulerSidecar:
+	case SchedulerOperator:
+		if r.Spec.Type == BackupOffline {
+			return fmt.Errorf("spec.scheduler %s is not supported for offline backup", r.Spec.Scheduler)
+		}
+	default:
+		return fmt.Errorf("spec.scheduler %s is invalid", r.Spec.Scheduler)
+	}
+	switch r.Spec.MissedRunPolicy {
+	case "", MissedRunPolicySkip, MissedRunPolicyQueueOne, MissedRunPolicyCatchUp:
+	default:
+		return fmt.Errorf("spec.missedRunPolicy %s is invalid", r.Spec.MissedRunPolicy)
+	}
+	return nil
+}
+
+// retentionDuration matches durations accepted by restic forget, eg: 1y6m or 14d.
+var retentionDuration = regexp.MustCompile(`^([0-9]+[ymdh])+$`)
+
+func (p RetentionPolicy) IsValid() error {
+	for _, keep := range []struct {
+		field string
+		count int
+	}{
+		{"keepLast", p.KeepLast},
+		{"keepHourly", p.KeepHourly},
+		{"keepDaily", p.KeepDaily},
+		{"keepWeekly", p.KeepWeekly},
+		{"keepMonthly", p.KeepMonthly},
+		{"keepYearly", p.KeepYearly},
+	} {
+		if keep.count < 0 {
+			return fmt.Errorf("%s %d is negative", keep.field, keep.count)
+		}
+	}
+	for _, keep := range []struct {
+		field    string
+		duration string
+	}{
+		{"keepWithin", p.KeepWithin},
+		{"keepWithinHourly", p.KeepWithinHourly},
+		{"keepWithinDaily", p.KeepWithinDaily},
+		{"keepWithinWeekly", p.KeepWithinWeekly},
+		{"keepWithinMonthly", p.KeepWithinMonthly},
+		{"keepWithinYearly", p.KeepWithinYearly},
+	} {
+		if keep.duration != "" && !retentionDuration.MatchString(keep.duration) {
+			return fmt.Errorf("%s %s is not a duration like 1y6m, 14d or 12h", keep.field, keep.duration)
+		}
+	}
+	seen := map[SnapshotGroupKey]bool{}
+	for _, key := range p.GroupBy {
+		switch key {
+		case GroupByHost, GroupByPaths, GroupByTags:
+		default:
+			return fmt.Errorf("groupBy %s is invalid", key)
+		}
+		if seen[key] {
+			return fmt.Errorf("groupBy %s is repeated", key)
+		}
+		seen[key] = true
+	}
+	return nil
+}
+
+func (h Hook) IsValid() error {
+	if h.Name == "" {
+		return fmt.Errorf("missing hook name")
+	}
+	if (h.Exec == nil) == (h.HTTP == nil) {
+		return fmt.Errorf("hook %s must specify exactly one of exec and http", h.Name)
+	}
+	if h.Exec != nil {
+		if h.Exec.Container == "" {
+			return fmt.Errorf("hook %s must specify exec container", h.Name)
+		}
+		if len(h.Exec.Command) == 0 {
+			return fmt.Errorf("hook %s must specify exec command", h.Name)
+		}
+	}
+	if h.HTTP != nil && (h.HTTP.Port <= 0 || h.HTTP.Port > 65535) {
+		return fmt.Errorf("hook %s has invalid http port %d", h.Name, h.HTTP.Port)
+	}
+	if h.TimeoutSeconds < 0 {
+		return fmt.Errorf("hook %s has negative timeoutSeconds", h.Name)
+	}
+	switch h.OnFailure {
+	case "", HookFailurePolicyAbort, HookFailurePolicyContinue:
+	default:
+		return fmt.Errorf("hook %s has invalid onFailure policy %s", h.Name, h.OnFailure)
+	}
 	return nil
 }
 
@@ -45,6 +204,12 @@ func (r Recovery) IsValid() error {
 		return fmt.Errorf("missing recovery volume")
 	}
 
+	for i, hook := range r.Spec.PostRestoreHooks {
+		if err := hook.IsValid(); err != nil {
+			return fmt.Errorf("spec.postRestoreHooks[%d] is invalid. Reason: %s", i, err)
+		}
+	}
+
 	if err := r.Spec.Workload.Canonicalize(); err != nil {
 		return err
 	}
@@ -71,3 +236,24 @@ func (r Recovery) IsValid() error {
 	}
 	return nil
 }
+
+func (h RecoveryHook) IsValid() error {
+	if h.Name == "" {
+		return fmt.Errorf("missing hook name")
+	}
+	if (len(h.Command) == 0) == (h.Exec == nil) {
+		return fmt.Errorf("hook %s must specify exactly one of command and exec", h.Name)
+	}
+	if h.Exec != nil {
+		if h.Exec.Pod == "" || h.Exec.Container == "" {
+			return fmt.Errorf("hook %s must specify exec pod and container", h.Name)
+		}
+		if len(h.Exec.Command) == 0 {
+			return fmt.Errorf("hook %s must specify exec command", h.Name)
+		}
+	}
+	if h.TimeoutSeconds < 0 {
+		return fmt.Errorf("hook %s has negative timeoutSeconds", h.Name)
+	}
+	return nil
+}
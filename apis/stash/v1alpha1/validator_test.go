@@ -0,0 +1,76 @@
+package v1alpha1
+
+import (
+	"testing"
+)
+
+func TestRetentionPolicyIsValid(t *testing.T) {
+	cases := []struct {
+		name   string
+		policy RetentionPolicy
+		valid  bool
+	}{
+		{"empty", RetentionPolicy{Name: "empty"}, true},
+		{"keep last", RetentionPolicy{KeepLast: 5, KeepDaily: 7}, true},
+		{"negative keep", RetentionPolicy{KeepWeekly: -1}, false},
+
+		{"keep within days", RetentionPolicy{KeepWithin: "14d"}, true},
+		{"keep within hours", RetentionPolicy{KeepWithin: "12h"}, true},
+		{"keep within combined units", RetentionPolicy{KeepWithin: "1y6m"}, true},
+		{"keep within all units", RetentionPolicy{KeepWithin: "2y5m7d3h"}, true},
+		{"keep within with keep last", RetentionPolicy{KeepLast: 3, KeepWithin: "30d"}, true},
+		{"keep within per period", RetentionPolicy{
+			KeepWithinHourly:  "2d",
+			KeepWithinDaily:   "14d",
+			KeepWithinWeekly:  "2m",
+			KeepWithinMonthly: "1y",
+			KeepWithinYearly:  "10y",
+		}, true},
+		{"keep within weeks", RetentionPolicy{KeepWithin: "2w"}, false},
+		{"keep within go duration", RetentionPolicy{KeepWithin: "336h0m0s"}, false},
+		{"keep within without unit", RetentionPolicy{KeepWithin: "14"}, false},
+		{"keep within without number", RetentionPolicy{KeepWithin: "d"}, false},
+		{"keep within negative", RetentionPolicy{KeepWithin: "-14d"}, false},
+		{"keep within fraction", RetentionPolicy{KeepWithin: "1.5d"}, false},
+		{"keep within with space", RetentionPolicy{KeepWithin: "14 d"}, false},
+		{"keep within upper case", RetentionPolicy{KeepWithin: "14D"}, false},
+		{"keep within daily invalid", RetentionPolicy{KeepWithin: "14d", KeepWithinDaily: "two weeks"}, false},
+		{"keep within yearly invalid", RetentionPolicy{KeepWithinYearly: "1y "}, false},
+
+		{"group by", RetentionPolicy{KeepLast: 1, GroupBy: []SnapshotGroupKey{GroupByHost, GroupByTags}}, true},
+		{"group by invalid", RetentionPolicy{KeepLast: 1, GroupBy: []SnapshotGroupKey{"node"}}, false},
+		{"group by repeated", RetentionPolicy{KeepLast: 1, GroupBy: []SnapshotGroupKey{GroupByPaths, GroupByPaths}}, false},
+	}
+	for _, c := range cases {
+		err := c.policy.IsValid()
+		if c.valid && err != nil {
+			t.Errorf("%s: expected valid, got %s", c.name, err)
+		}
+		if !c.valid && err == nil {
+			t.Errorf("%s: expected error", c.name)
+		}
+	}
+}
+
+func TestResticIsValidRetentionPolicy(t *testing.T) {
+	restic := Restic{
+		Spec: ResticSpec{
+			Schedule: "@every 1h",
+			Backend:  Backend{StorageSecretName: "s3-secret"},
+			FileGroups: []FileGroup{
+				{Path: "/source/data", RetentionPolicyName: "keep-last-month"},
+			},
+			RetentionPolicies: []RetentionPolicy{
+				{Name: "keep-last-month", KeepWithin: "1m"},
+			},
+		},
+	}
+	if err := restic.IsValid(); err != nil {
+		t.Errorf("expected valid Restic, got %s", err)
+	}
+
+	restic.Spec.RetentionPolicies[0].KeepWithin = "1 month"
+	if err := restic.IsValid(); err == nil {
+		t.Errorf("expected error for invalid keepWithin")
+	}
+}
@@ -21,8 +21,8 @@ limitations under the License.
 package v1alpha1
 
 import (
-	v1 "k8s.io/api/core/v1"
-	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	core_v1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -115,6 +115,33 @@ func (in *Backend) DeepCopyInto(out *Backend) {
 			**out = **in
 		}
 	}
+	if in.Rest != nil {
+		in, out := &in.Rest, &out.Rest
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(RestServerSpec)
+			**out = **in
+		}
+	}
+	if in.SFTP != nil {
+		in, out := &in.SFTP, &out.SFTP
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(SFTPSpec)
+			**out = **in
+		}
+	}
+	if in.Rclone != nil {
+		in, out := &in.Rclone, &out.Rclone
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(RcloneSpec)
+			**out = **in
+		}
+	}
 	return
 }
 
@@ -128,6 +155,240 @@ func (in *Backend) DeepCopy() *Backend {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupHooks) DeepCopyInto(out *BackupHooks) {
+	*out = *in
+	if in.PreBackup != nil {
+		in, out := &in.PreBackup, &out.PreBackup
+		*out = make([]Hook, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PostBackup != nil {
+		in, out := &in.PostBackup, &out.PostBackup
+		*out = make([]Hook, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupHooks.
+func (in *BackupHooks) DeepCopy() *BackupHooks {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupHooks)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupSession) DeepCopyInto(out *BackupSession) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupSession.
+func (in *BackupSession) DeepCopy() *BackupSession {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupSession)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *BackupSession) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	} else {
+		return nil
+	}
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupSessionList) DeepCopyInto(out *BackupSessionList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]BackupSession, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupSessionList.
+func (in *BackupSessionList) DeepCopy() *BackupSessionList {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupSessionList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *BackupSessionList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	} else {
+		return nil
+	}
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupSessionSpec) DeepCopyInto(out *BackupSessionSpec) {
+	*out = *in
+	out.Workload = in.Workload
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupSessionSpec.
+func (in *BackupSessionSpec) DeepCopy() *BackupSessionSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupSessionSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupSessionStatus) DeepCopyInto(out *BackupSessionStatus) {
+	*out = *in
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(v1.Time)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(v1.Time)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	if in.FileGroups != nil {
+		in, out := &in.FileGroups, &out.FileGroups
+		*out = make([]FileGroupStats, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupSessionStatus.
+func (in *BackupSessionStatus) DeepCopy() *BackupSessionStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupSessionStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BlackoutWindow) DeepCopyInto(out *BlackoutWindow) {
+	*out = *in
+	if in.Days != nil {
+		in, out := &in.Days, &out.Days
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BlackoutWindow.
+func (in *BlackoutWindow) DeepCopy() *BlackoutWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(BlackoutWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExecHook) DeepCopyInto(out *ExecHook) {
+	*out = *in
+	if in.Command != nil {
+		in, out := &in.Command, &out.Command
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExecHook.
+func (in *ExecHook) DeepCopy() *ExecHook {
+	if in == nil {
+		return nil
+	}
+	out := new(ExecHook)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExecutionPolicy) DeepCopyInto(out *ExecutionPolicy) {
+	*out = *in
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(v1.Duration)
+			**out = **in
+		}
+	}
+	if in.Backoff != nil {
+		in, out := &in.Backoff, &out.Backoff
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(v1.Duration)
+			**out = **in
+		}
+	}
+	if in.StaleLockTimeout != nil {
+		in, out := &in.StaleLockTimeout, &out.StaleLockTimeout
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(v1.Duration)
+			**out = **in
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExecutionPolicy.
+func (in *ExecutionPolicy) DeepCopy() *ExecutionPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(ExecutionPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *FileGroup) DeepCopyInto(out *FileGroup) {
 	*out = *in
@@ -149,6 +410,22 @@ func (in *FileGroup) DeepCopy() *FileGroup {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FileGroupStats) DeepCopyInto(out *FileGroupStats) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FileGroupStats.
+func (in *FileGroupStats) DeepCopy() *FileGroupStats {
+	if in == nil {
+		return nil
+	}
+	out := new(FileGroupStats)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GCSSpec) DeepCopyInto(out *GCSSpec) {
 	*out = *in
@@ -165,6 +442,77 @@ func (in *GCSSpec) DeepCopy() *GCSSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HTTPHook) DeepCopyInto(out *HTTPHook) {
+	*out = *in
+	if in.HTTPHeaders != nil {
+		in, out := &in.HTTPHeaders, &out.HTTPHeaders
+		*out = make([]core_v1.HTTPHeader, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPHook.
+func (in *HTTPHook) DeepCopy() *HTTPHook {
+	if in == nil {
+		return nil
+	}
+	out := new(HTTPHook)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Hook) DeepCopyInto(out *Hook) {
+	*out = *in
+	if in.Exec != nil {
+		in, out := &in.Exec, &out.Exec
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(ExecHook)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	if in.HTTP != nil {
+		in, out := &in.HTTP, &out.HTTP
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(HTTPHook)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Hook.
+func (in *Hook) DeepCopy() *Hook {
+	if in == nil {
+		return nil
+	}
+	out := new(Hook)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HookResult) DeepCopyInto(out *HookResult) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HookResult.
+func (in *HookResult) DeepCopy() *HookResult {
+	if in == nil {
+		return nil
+	}
+	out := new(HookResult)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *LocalSpec) DeepCopyInto(out *LocalSpec) {
 	*out = *in
@@ -198,6 +546,39 @@ func (in *LocalTypedReference) DeepCopy() *LocalTypedReference {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PodExecHook) DeepCopyInto(out *PodExecHook) {
+	*out = *in
+	in.ExecHook.DeepCopyInto(&out.ExecHook)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodExecHook.
+func (in *PodExecHook) DeepCopy() *PodExecHook {
+	if in == nil {
+		return nil
+	}
+	out := new(PodExecHook)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RcloneSpec) DeepCopyInto(out *RcloneSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RcloneSpec.
+func (in *RcloneSpec) DeepCopy() *RcloneSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RcloneSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Recovery) DeepCopyInto(out *Recovery) {
 	*out = *in
@@ -227,6 +608,36 @@ func (in *Recovery) DeepCopyObject() runtime.Object {
 	}
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RecoveryHook) DeepCopyInto(out *RecoveryHook) {
+	*out = *in
+	if in.Command != nil {
+		in, out := &in.Command, &out.Command
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Exec != nil {
+		in, out := &in.Exec, &out.Exec
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(PodExecHook)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecoveryHook.
+func (in *RecoveryHook) DeepCopy() *RecoveryHook {
+	if in == nil {
+		return nil
+	}
+	out := new(RecoveryHook)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RecoveryList) DeepCopyInto(out *RecoveryList) {
 	*out = *in
@@ -280,9 +691,16 @@ func (in *RecoverySpec) DeepCopyInto(out *RecoverySpec) {
 	}
 	if in.ImagePullSecrets != nil {
 		in, out := &in.ImagePullSecrets, &out.ImagePullSecrets
-		*out = make([]v1.LocalObjectReference, len(*in))
+		*out = make([]core_v1.LocalObjectReference, len(*in))
 		copy(*out, *in)
 	}
+	if in.PostRestoreHooks != nil {
+		in, out := &in.PostRestoreHooks, &out.PostRestoreHooks
+		*out = make([]RecoveryHook, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -304,6 +722,20 @@ func (in *RecoveryStatus) DeepCopyInto(out *RecoveryStatus) {
 		*out = make([]RestoreStats, len(*in))
 		copy(*out, *in)
 	}
+	if in.Progress != nil {
+		in, out := &in.Progress, &out.Progress
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(ResticProgress)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	if in.HookResults != nil {
+		in, out := &in.HookResults, &out.HookResults
+		*out = make([]HookResult, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -317,6 +749,28 @@ func (in *RecoveryStatus) DeepCopy() *RecoveryStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RemovedSnapshot) DeepCopyInto(out *RemovedSnapshot) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+	if in.Paths != nil {
+		in, out := &in.Paths, &out.Paths
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RemovedSnapshot.
+func (in *RemovedSnapshot) DeepCopy() *RemovedSnapshot {
+	if in == nil {
+		return nil
+	}
+	out := new(RemovedSnapshot)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Repository) DeepCopyInto(out *Repository) {
 	*out = *in
@@ -346,6 +800,23 @@ func (in *Repository) DeepCopyObject() runtime.Object {
 	}
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RepositoryCondition) DeepCopyInto(out *RepositoryCondition) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RepositoryCondition.
+func (in *RepositoryCondition) DeepCopy() *RepositoryCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(RepositoryCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RepositoryList) DeepCopyInto(out *RepositoryList) {
 	*out = *in
@@ -380,6 +851,47 @@ func (in *RepositoryList) DeepCopyObject() runtime.Object {
 	}
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RepositoryMaintenance) DeepCopyInto(out *RepositoryMaintenance) {
+	*out = *in
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(v1.Time)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(v1.Time)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	if in.RemovedSnapshots != nil {
+		in, out := &in.RemovedSnapshots, &out.RemovedSnapshots
+		*out = make([]RemovedSnapshot, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RepositoryMaintenance.
+func (in *RepositoryMaintenance) DeepCopy() *RepositoryMaintenance {
+	if in == nil {
+		return nil
+	}
+	out := new(RepositoryMaintenance)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RepositorySpec) DeepCopyInto(out *RepositorySpec) {
 	*out = *in
@@ -405,7 +917,7 @@ func (in *RepositoryStatus) DeepCopyInto(out *RepositoryStatus) {
 		if *in == nil {
 			*out = nil
 		} else {
-			*out = new(meta_v1.Time)
+			*out = new(v1.Time)
 			(*in).DeepCopyInto(*out)
 		}
 	}
@@ -414,7 +926,7 @@ func (in *RepositoryStatus) DeepCopyInto(out *RepositoryStatus) {
 		if *in == nil {
 			*out = nil
 		} else {
-			*out = new(meta_v1.Time)
+			*out = new(v1.Time)
 			(*in).DeepCopyInto(*out)
 		}
 	}
@@ -423,7 +935,41 @@ func (in *RepositoryStatus) DeepCopyInto(out *RepositoryStatus) {
 		if *in == nil {
 			*out = nil
 		} else {
-			*out = new(meta_v1.Time)
+			*out = new(v1.Time)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	if in.LastFailedBackupTime != nil {
+		in, out := &in.LastFailedBackupTime, &out.LastFailedBackupTime
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(v1.Time)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	if in.BackupProgress != nil {
+		in, out := &in.BackupProgress, &out.BackupProgress
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(ResticProgress)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]RepositoryCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LastMaintenance != nil {
+		in, out := &in.LastMaintenance, &out.LastMaintenance
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(RepositoryMaintenance)
 			(*in).DeepCopyInto(*out)
 		}
 	}
@@ -518,6 +1064,31 @@ func (in *ResticList) DeepCopyObject() runtime.Object {
 	}
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResticProgress) DeepCopyInto(out *ResticProgress) {
+	*out = *in
+	if in.LastUpdateTime != nil {
+		in, out := &in.LastUpdateTime, &out.LastUpdateTime
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(v1.Time)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResticProgress.
+func (in *ResticProgress) DeepCopy() *ResticProgress {
+	if in == nil {
+		return nil
+	}
+	out := new(ResticProgress)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ResticSpec) DeepCopyInto(out *ResticSpec) {
 	*out = *in
@@ -532,7 +1103,7 @@ func (in *ResticSpec) DeepCopyInto(out *ResticSpec) {
 	in.Backend.DeepCopyInto(&out.Backend)
 	if in.VolumeMounts != nil {
 		in, out := &in.VolumeMounts, &out.VolumeMounts
-		*out = make([]v1.VolumeMount, len(*in))
+		*out = make([]core_v1.VolumeMount, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
@@ -547,9 +1118,61 @@ func (in *ResticSpec) DeepCopyInto(out *ResticSpec) {
 	}
 	if in.ImagePullSecrets != nil {
 		in, out := &in.ImagePullSecrets, &out.ImagePullSecrets
-		*out = make([]v1.LocalObjectReference, len(*in))
+		*out = make([]core_v1.LocalObjectReference, len(*in))
 		copy(*out, *in)
 	}
+	if in.Hooks != nil {
+		in, out := &in.Hooks, &out.Hooks
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(BackupHooks)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	if in.BackupHistoryLimit != nil {
+		in, out := &in.BackupHistoryLimit, &out.BackupHistoryLimit
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(int32)
+			**out = **in
+		}
+	}
+	if in.ExecutionPolicy != nil {
+		in, out := &in.ExecutionPolicy, &out.ExecutionPolicy
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(ExecutionPolicy)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	if in.BlackoutWindows != nil {
+		in, out := &in.BlackoutWindows, &out.BlackoutWindows
+		*out = make([]BlackoutWindow, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Jitter != nil {
+		in, out := &in.Jitter, &out.Jitter
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(v1.Duration)
+			**out = **in
+		}
+	}
+	if in.MaxRepositorySize != nil {
+		in, out := &in.MaxRepositorySize, &out.MaxRepositorySize
+		if *in == nil {
+			*out = nil
+		} else {
+			x := (*in).DeepCopy()
+			*out = &x
+		}
+	}
 	return
 }
 
@@ -587,6 +1210,11 @@ func (in *RetentionPolicy) DeepCopyInto(out *RetentionPolicy) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.GroupBy != nil {
+		in, out := &in.GroupBy, &out.GroupBy
+		*out = make([]SnapshotGroupKey, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -616,6 +1244,22 @@ func (in *S3Spec) DeepCopy() *S3Spec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SFTPSpec) DeepCopyInto(out *SFTPSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SFTPSpec.
+func (in *SFTPSpec) DeepCopy() *SFTPSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SFTPSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SwiftSpec) DeepCopyInto(out *SwiftSpec) {
 	*out = *in
@@ -26,6 +26,10 @@ type FakeStashV1alpha1 struct {
 	*testing.Fake
 }
 
+func (c *FakeStashV1alpha1) BackupSessions(namespace string) v1alpha1.BackupSessionInterface {
+	return &FakeBackupSessions{c, namespace}
+}
+
 func (c *FakeStashV1alpha1) Recoveries(namespace string) v1alpha1.RecoveryInterface {
 	return &FakeRecoveries{c, namespace}
 }
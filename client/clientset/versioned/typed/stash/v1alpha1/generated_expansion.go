@@ -16,6 +16,8 @@ limitations under the License.
 
 package v1alpha1
 
+type BackupSessionExpansion interface{}
+
 type RecoveryExpansion interface{}
 
 type RepositoryExpansion interface{}
@@ -25,6 +25,7 @@ import (
 
 type StashV1alpha1Interface interface {
 	RESTClient() rest.Interface
+	BackupSessionsGetter
 	RecoveriesGetter
 	RepositoriesGetter
 	ResticsGetter
@@ -35,6 +36,10 @@ type StashV1alpha1Client struct {
 	restClient rest.Interface
 }
 
+func (c *StashV1alpha1Client) BackupSessions(namespace string) BackupSessionInterface {
+	return newBackupSessions(c, namespace)
+}
+
 func (c *StashV1alpha1Client) Recoveries(namespace string) RecoveryInterface {
 	return newRecoveries(c, namespace)
 }
@@ -95,26 +95,36 @@ func SetRecoveryStatus(c cs.StashV1alpha1Interface, rec *api.Recovery, status ap
 }
 
 func SetRecoveryStatusPhase(c cs.StashV1alpha1Interface, rec *api.Recovery, phase api.RecoveryPhase) {
-	SetRecoveryStatus(c, rec, api.RecoveryStatus{Phase: phase})
+	_, _, err := PatchRecovery(c, rec, func(in *api.Recovery) *api.Recovery {
+		in.Status.Phase = phase
+		in.Status.Progress = nil
+		return in
+	})
+	if err != nil {
+		log.Errorln("Error updating recovery phase:", phase, "reason:", err)
+	} else {
+		log.Infoln("Updated recovery phase:", phase)
+	}
 }
 
 func SetRecoveryStats(c cs.StashV1alpha1Interface, recovery *api.Recovery, path string, d time.Duration, phase api.RecoveryPhase) (*api.Recovery, error) {
 	out, _, err := PatchRecovery(c, recovery, func(in *api.Recovery) *api.Recovery {
 		found := false
-		for _, stats := range in.Status.Stats {
-			if stats.Path == path {
+		for i := range in.Status.Stats {
+			if in.Status.Stats[i].Path == path {
 				found = true
-				stats.Duration = d.String()
-				stats.Phase = phase
+				in.Status.Stats[i].Duration = d.String()
+				in.Status.Stats[i].Phase = phase
 			}
 		}
 		if !found {
-			recovery.Status.Stats = append(recovery.Status.Stats, api.RestoreStats{
+			in.Status.Stats = append(in.Status.Stats, api.RestoreStats{
 				Path:     path,
 				Duration: d.String(),
 				Phase:    phase,
 			})
 		}
+		in.Status.Progress = nil
 		return in
 	})
 	return out, err
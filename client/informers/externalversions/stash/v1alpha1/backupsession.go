@@ -0,0 +1,89 @@
+/*
+Copyright 2018 The Stash Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// This file was automatically generated by informer-gen
+
+package v1alpha1
+
+import (
+	time "time"
+
+	stash_v1alpha1 "github.com/appscode/stash/apis/stash/v1alpha1"
+	versioned "github.com/appscode/stash/client/clientset/versioned"
+	internalinterfaces "github.com/appscode/stash/client/informers/externalversions/internalinterfaces"
+	v1alpha1 "github.com/appscode/stash/client/listers/stash/v1alpha1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+	watch "k8s.io/apimachinery/pkg/watch"
+	cache "k8s.io/client-go/tools/cache"
+)
+
+// BackupSessionInformer provides access to a shared informer and lister for
+// BackupSessions.
+type BackupSessionInformer interface {
+	Informer() cache.SharedIndexInformer
+	Lister() v1alpha1.BackupSessionLister
+}
+
+type backupSessionInformer struct {
+	factory          internalinterfaces.SharedInformerFactory
+	tweakListOptions internalinterfaces.TweakListOptionsFunc
+	namespace        string
+}
+
+// NewBackupSessionInformer constructs a new informer for BackupSession type.
+// Always prefer using an informer factory to get a shared informer instead of getting an independent
+// one. This reduces memory footprint and number of connections to the server.
+func NewBackupSessionInformer(client versioned.Interface, namespace string, resyncPeriod time.Duration, indexers cache.Indexers) cache.SharedIndexInformer {
+	return NewFilteredBackupSessionInformer(client, namespace, resyncPeriod, indexers, nil)
+}
+
+// NewFilteredBackupSessionInformer constructs a new informer for BackupSession type.
+// Always prefer using an informer factory to get a shared informer instead of getting an independent
+// one. This reduces memory footprint and number of connections to the server.
+func NewFilteredBackupSessionInformer(client versioned.Interface, namespace string, resyncPeriod time.Duration, indexers cache.Indexers, tweakListOptions internalinterfaces.TweakListOptionsFunc) cache.SharedIndexInformer {
+	return cache.NewSharedIndexInformer(
+		&cache.ListWatch{
+			ListFunc: func(options v1.ListOptions) (runtime.Object, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.StashV1alpha1().BackupSessions(namespace).List(options)
+			},
+			WatchFunc: func(options v1.ListOptions) (watch.Interface, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.StashV1alpha1().BackupSessions(namespace).Watch(options)
+			},
+		},
+		&stash_v1alpha1.BackupSession{},
+		resyncPeriod,
+		indexers,
+	)
+}
+
+func (f *backupSessionInformer) defaultInformer(client versioned.Interface, resyncPeriod time.Duration) cache.SharedIndexInformer {
+	return NewFilteredBackupSessionInformer(client, f.namespace, resyncPeriod, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}, f.tweakListOptions)
+}
+
+func (f *backupSessionInformer) Informer() cache.SharedIndexInformer {
+	return f.factory.InformerFor(&stash_v1alpha1.BackupSession{}, f.defaultInformer)
+}
+
+func (f *backupSessionInformer) Lister() v1alpha1.BackupSessionLister {
+	return v1alpha1.NewBackupSessionLister(f.Informer().GetIndexer())
+}
@@ -18,6 +18,14 @@ limitations under the License.
 
 package v1alpha1
 
+// BackupSessionListerExpansion allows custom methods to be added to
+// BackupSessionLister.
+type BackupSessionListerExpansion interface{}
+
+// BackupSessionNamespaceListerExpansion allows custom methods to be added to
+// BackupSessionNamespaceLister.
+type BackupSessionNamespaceListerExpansion interface{}
+
 // RecoveryListerExpansion allows custom methods to be added to
 // RecoveryLister.
 type RecoveryListerExpansion interface{}
@@ -14,7 +14,8 @@ source "$REPO_ROOT/hack/libbuild/common/public_image.sh"
 
 APPSCODE_ENV=${APPSCODE_ENV:-dev}
 IMG=stash
-RESTIC_VER=${RESTIC_VER:-0.8.3}
+RESTIC_VER=${RESTIC_VER:-0.16.0}
+RCLONE_VER=${RCLONE_VER:-1.42}
 RESTIC_BRANCH=${RESTIC_BRANCH:-stash-0.4.2}
 
 DIST=$REPO_ROOT/dist
@@ -25,7 +26,7 @@ fi
 
 clean() {
     pushd $REPO_ROOT/hack/docker
-    rm -rf restic stash Dockerfile
+    rm -rf restic rclone stash Dockerfile
     popd
 }
 
@@ -58,6 +59,15 @@ build_binary() {
         mv restic_${RESTIC_VER}_linux_amd64 restic
     fi
 
+    # Download rclone, used by restic for rclone backend
+    rm -rf $DIST/rclone
+    mkdir $DIST/rclone
+    cd $DIST/rclone
+    wget https://downloads.rclone.org/v${RCLONE_VER}/rclone-v${RCLONE_VER}-linux-amd64.zip
+    unzip rclone-v${RCLONE_VER}-linux-amd64.zip
+    mv rclone-v${RCLONE_VER}-linux-amd64/rclone rclone
+    rm -rf rclone-v${RCLONE_VER}-linux-amd64 rclone-v${RCLONE_VER}-linux-amd64.zip
+
     popd
 }
 
@@ -71,13 +81,17 @@ build_docker() {
     cp $DIST/restic/restic restic
     chmod 755 restic
 
+    cp $DIST/rclone/rclone rclone
+    chmod 755 rclone
+
     cat >Dockerfile <<EOL
 FROM alpine
 
 RUN set -x \
-  && apk add --update --no-cache ca-certificates
+  && apk add --update --no-cache ca-certificates openssh-client tzdata
 
 COPY restic /bin/restic
+COPY rclone /bin/rclone
 COPY stash /bin/stash
 
 ENTRYPOINT ["/bin/stash"]
@@ -86,7 +100,7 @@ EOL
     local cmd="docker build -t appscode/$IMG:$TAG ."
     echo $cmd; $cmd
 
-    rm stash Dockerfile restic
+    rm stash Dockerfile restic rclone
     popd
 }
 
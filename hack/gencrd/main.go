@@ -36,6 +36,7 @@ func generateCRDDefinitions() {
 		stashv1alpha1.Restic{}.CustomResourceDefinition(),
 		stashv1alpha1.Recovery{}.CustomResourceDefinition(),
 		stashv1alpha1.Repository{}.CustomResourceDefinition(),
+		stashv1alpha1.BackupSession{}.CustomResourceDefinition(),
 	}
 	for _, crd := range crds {
 		crdutils.MarshallCrd(f, crd, "yaml")
@@ -77,6 +78,7 @@ func generateSwaggerJson() {
 			stashv1alpha1.SchemeGroupVersion.WithResource(stashv1alpha1.ResourcePluralRestic),
 			stashv1alpha1.SchemeGroupVersion.WithResource(stashv1alpha1.ResourcePluralRepository),
 			stashv1alpha1.SchemeGroupVersion.WithResource(stashv1alpha1.ResourcePluralRecovery),
+			stashv1alpha1.SchemeGroupVersion.WithResource(stashv1alpha1.ResourcePluralBackupSession),
 		},
 		RDResources: []schema.GroupVersionResource{
 			repov1alpha1.SchemeGroupVersion.WithResource(repov1alpha1.ResourcePluralSnapshot),
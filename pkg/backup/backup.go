@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/appscode/go/log"
@@ -19,6 +20,7 @@ import (
 	"github.com/appscode/stash/pkg/controller"
 	"github.com/appscode/stash/pkg/docker"
 	"github.com/appscode/stash/pkg/eventer"
+	"github.com/appscode/stash/pkg/hooks"
 	"github.com/appscode/stash/pkg/util"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/push"
@@ -29,6 +31,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/tools/reference"
@@ -61,24 +64,40 @@ type Controller struct {
 	stashClient cs.Interface
 	opt         Options
 	locked      chan struct{}
+	// number of scheduled backups to run when the lock is released
+	pendingRuns int
+	pendingMu   sync.Mutex
+	// request time of the last handled backup trigger
+	lastTrigger time.Time
 	resticCLI   *cli.ResticWrapper
 	cron        *cron.Cron
 	recorder    record.EventRecorder
+	hooks       *hooks.Executor
+
+	// value of the last handled backup dispatch of operator
+	lastDispatch string
 
 	stashInformerFactory stashinformers.SharedInformerFactory
 
 	// Restic
 	rQueue    *queue.Worker
+	tQueue    *queue.Worker
 	rInformer cache.SharedIndexInformer
 	rLister   stash_listers.ResticLister
+
+	// Repository
+	repoInformer cache.SharedIndexInformer
 }
 
 const (
 	CheckRole            = "stash-check"
 	BackupEventComponent = "stash-backup"
+
+	// Minimum interval between progress updates of a running backup
+	progressUpdateInterval = 30 * time.Second
 )
 
-func New(k8sClient kubernetes.Interface, stashClient cs.Interface, opt Options) *Controller {
+func New(k8sClient kubernetes.Interface, stashClient cs.Interface, config *rest.Config, opt Options) *Controller {
 	return &Controller{
 		k8sClient:   k8sClient,
 		stashClient: stashClient,
@@ -87,6 +106,7 @@ func New(k8sClient kubernetes.Interface, stashClient cs.Interface, opt Options)
 		locked:      make(chan struct{}, 1),
 		resticCLI:   cli.New(opt.ScratchDir, true, opt.SnapshotHostname),
 		recorder:    eventer.NewEventRecorder(k8sClient, BackupEventComponent),
+		hooks:       hooks.New(k8sClient, config),
 		stashInformerFactory: stashinformers.NewFilteredSharedInformerFactory(
 			stashClient,
 			opt.ResyncPeriod,
@@ -132,7 +152,7 @@ func (c *Controller) Backup() error {
 		Tag:      c.opt.ImageTag,
 	}
 
-	job := util.NewCheckJob(restic, c.opt.SnapshotHostname, c.opt.SmartPrefix, image)
+	job := util.NewCheckJob(restic, c.opt.Workload, c.opt.SnapshotHostname, c.opt.SmartPrefix, c.opt.PushgatewayURL, image)
 
 	// check if check job exists
 	if _, err = c.k8sClient.BatchV1().Jobs(restic.Namespace).Get(job.Name, metav1.GetOptions{}); err != nil && !errors.IsNotFound(err) {
@@ -178,6 +198,9 @@ func (c *Controller) Backup() error {
 			if err = c.ensureCheckRBAC(ref); err != nil {
 				return fmt.Errorf("error ensuring rbac for check job %s, reason: %s\n", job.Name, err)
 			}
+			if err = controller.EnsurePodsRBAC(c.k8sClient, ref, controller.GetPodsRoleName(job.Name), job.Name, []rbac.PolicyRule{controller.LockPodsRule}); err != nil {
+				return fmt.Errorf("error ensuring rbac for check job %s, reason: %s\n", job.Name, err)
+			}
 		}
 
 		log.Infoln("Created check job:", job.Name)
@@ -268,6 +291,12 @@ func (c *Controller) runResticBackup(restic *api.Restic, repository *api.Reposit
 			Name:      "fail",
 			Help:      "Indicates if session failed",
 		})
+		restic_session_error = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "restic",
+			Subsystem: "session",
+			Name:      "error",
+			Help:      "Indicates the type of error that failed the session",
+		}, []string{"type"})
 		restic_session_duration_seconds_total = prometheus.NewGauge(prometheus.GaugeOpts{
 			Namespace: "restic",
 			Subsystem: "session",
@@ -280,14 +309,88 @@ func (c *Controller) runResticBackup(restic *api.Restic, repository *api.Reposit
 			Name:      "duration_seconds",
 			Help:      "Total seconds taken to complete restic session",
 		}, []string{"filegroup", "op"})
+		restic_session_files_new = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "restic",
+			Subsystem: "session",
+			Name:      "files_new",
+			Help:      "Number of new files backed up in restic session",
+		}, []string{"filegroup"})
+		restic_session_files_changed = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "restic",
+			Subsystem: "session",
+			Name:      "files_changed",
+			Help:      "Number of changed files backed up in restic session",
+		}, []string{"filegroup"})
+		restic_session_files_unmodified = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "restic",
+			Subsystem: "session",
+			Name:      "files_unmodified",
+			Help:      "Number of unmodified files found in restic session",
+		}, []string{"filegroup"})
+		restic_session_data_added_bytes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "restic",
+			Subsystem: "session",
+			Name:      "data_added_bytes",
+			Help:      "Bytes of new data added to repository in restic session",
+		}, []string{"filegroup"})
+		restic_session_processed_bytes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "restic",
+			Subsystem: "session",
+			Name:      "processed_bytes",
+			Help:      "Total bytes processed in restic session",
+		}, []string{"filegroup"})
+		restic_session_snapshot_info = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "restic",
+			Subsystem: "session",
+			Name:      "snapshot_info",
+			Help:      "Snapshot created in restic session, value is always 1",
+		}, []string{"filegroup", "snapshot_id"})
+		restic_session_progress_percent = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "restic",
+			Subsystem: "session",
+			Name:      "progress_percent",
+			Help:      "Percentage of bytes processed by running backup",
+		}, []string{"filegroup"})
+		restic_session_progress_bytes_done = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "restic",
+			Subsystem: "session",
+			Name:      "progress_bytes_done",
+			Help:      "Bytes processed by running backup",
+		}, []string{"filegroup"})
+		restic_session_progress_total_bytes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "restic",
+			Subsystem: "session",
+			Name:      "progress_total_bytes",
+			Help:      "Total bytes to be processed by running backup",
+		}, []string{"filegroup"})
+		restic_session_progress_eta_seconds = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "restic",
+			Subsystem: "session",
+			Name:      "progress_eta_seconds",
+			Help:      "Estimated seconds remaining to complete running backup",
+		}, []string{"filegroup"})
 	)
+	progressCollectors := []prometheus.Collector{
+		restic_session_progress_percent,
+		restic_session_progress_bytes_done,
+		restic_session_progress_total_bytes,
+		restic_session_progress_eta_seconds,
+	}
+
+	session := c.createBackupSession(restic, repository, startTime)
+	var fgStats []api.FileGroupStats
 
 	defer func() {
 		endTime := metav1.Now()
+		c.completeBackupSession(restic, session, fgStats, err)
 		if c.opt.PushgatewayURL != "" {
+			for _, t := range cli.ErrorTypes {
+				restic_session_error.WithLabelValues(string(t)).Set(0)
+			}
 			if err != nil {
 				restic_session_success.Set(0)
 				restic_session_fail.Set(1)
+				restic_session_error.WithLabelValues(string(cli.ErrorTypeOf(err))).Set(1)
 			} else {
 				restic_session_success.Set(1)
 				restic_session_fail.Set(0)
@@ -297,42 +400,107 @@ func (c *Controller) runResticBackup(restic *api.Restic, repository *api.Reposit
 			push.Collectors(c.JobName(restic),
 				c.GroupingKeys(restic),
 				c.opt.PushgatewayURL,
-				restic_session_success,
-				restic_session_fail,
-				restic_session_duration_seconds_total,
-				restic_session_duration_seconds)
+				append([]prometheus.Collector{
+					restic_session_success,
+					restic_session_fail,
+					restic_session_error,
+					restic_session_duration_seconds_total,
+					restic_session_duration_seconds,
+					restic_session_files_new,
+					restic_session_files_changed,
+					restic_session_files_unmodified,
+					restic_session_data_added_bytes,
+					restic_session_processed_bytes,
+					restic_session_snapshot_info,
+				}, progressCollectors...)...)
 		}
-		if err == nil {
-			stash_util.PatchRepository(c.stashClient.StashV1alpha1(), repository, func(in *api.Repository) *api.Repository {
+		stash_util.PatchRepository(c.stashClient.StashV1alpha1(), repository, func(in *api.Repository) *api.Repository {
+			in.Status.BackupProgress = nil
+			in.Status.LastBackupTime = &startTime
+			in.Status.LastBackupDuration = endTime.Sub(startTime.Time).String()
+			if err == nil {
 				in.Status.BackupCount++
-				in.Status.LastBackupTime = &startTime
+				in.Status.LastSuccessfulBackupTime = &startTime
 				if in.Status.FirstBackupTime == nil {
 					in.Status.FirstBackupTime = &startTime
 				}
-				in.Status.LastBackupDuration = endTime.Sub(startTime.Time).String()
-				return in
-			})
-		}
+				in.Status.LastBackupError = ""
+			} else {
+				in.Status.LastFailedBackupTime = &startTime
+				in.Status.LastBackupError = err.Error()
+			}
+			return in
+		})
 	}()
 
+	if restic.Spec.Hooks != nil {
+		defer func() {
+			// post backup hooks run even if backup failed, their failure fails a successful session
+			if herr := c.runHooks(restic.Spec.Hooks.PostBackup, repository, "post-backup"); err == nil {
+				err = herr
+			}
+		}()
+		if err = c.runHooks(restic.Spec.Hooks.PreBackup, repository, "pre-backup"); err != nil {
+			return
+		}
+	}
+
+	continueOnFailure := restic.Spec.ExecutionPolicy != nil &&
+		restic.Spec.ExecutionPolicy.OnFileGroupFailure == api.FileGroupFailurePolicyContinue
+	var (
+		failedPaths []string
+		fgErr       error
+	)
 	for _, fg := range restic.Spec.FileGroups {
 		backupOpMetric := restic_session_duration_seconds.WithLabelValues(sanitizeLabelValue(fg.Path), "backup")
-		err = c.measure(c.resticCLI.Backup, restic, fg, backupOpMetric)
+		fgLabel := sanitizeLabelValue(fg.Path)
+		progress := cli.ThrottleProgress(progressUpdateInterval, func(p cli.Progress) {
+			restic_session_progress_percent.WithLabelValues(fgLabel).Set(p.PercentDone * 100)
+			restic_session_progress_bytes_done.WithLabelValues(fgLabel).Set(float64(p.BytesDone))
+			restic_session_progress_total_bytes.WithLabelValues(fgLabel).Set(float64(p.TotalBytes))
+			restic_session_progress_eta_seconds.WithLabelValues(fgLabel).Set(float64(p.SecondsRemaining))
+			if c.opt.PushgatewayURL != "" {
+				if err := push.AddCollectors(c.JobName(restic), c.GroupingKeys(restic), c.opt.PushgatewayURL, progressCollectors...); err != nil {
+					log.Errorf("Failed to push backup progress, reason: %s\n", err)
+				}
+			}
+			stash_util.PatchRepository(c.stashClient.StashV1alpha1(), repository, func(in *api.Repository) *api.Repository {
+				in.Status.BackupProgress = util.NewResticProgress(fg.Path, p)
+				return in
+			})
+		})
+
+		var summary *cli.BackupSummary
+		fgStartTime := time.Now()
+		err = c.measure(func() (err error) {
+			summary, err = c.backupFileGroup(restic, repository, fg, progress)
+			return
+		}, backupOpMetric)
 		if err != nil {
-			log.Errorf("Backup failed for Repository %s/%s, reason: %s\n", repository.Namespace, repository.Name, err)
-			ref, rerr := reference.GetReference(scheme.Scheme, repository)
-			if rerr == nil {
-				eventer.CreateEventWithLog(
-					c.k8sClient,
-					BackupEventComponent,
-					ref,
-					core.EventTypeWarning,
-					eventer.EventReasonFailedToBackup,
-					fmt.Sprintf("Backup failed, reason: %s", err),
-				)
+			if continueOnFailure {
+				failedPaths = append(failedPaths, fg.Path)
+				fgErr = err
+				continue
 			}
 			return
 		} else {
+			restic_session_files_new.WithLabelValues(fgLabel).Set(float64(summary.FilesNew))
+			restic_session_files_changed.WithLabelValues(fgLabel).Set(float64(summary.FilesChanged))
+			restic_session_files_unmodified.WithLabelValues(fgLabel).Set(float64(summary.FilesUnmodified))
+			restic_session_data_added_bytes.WithLabelValues(fgLabel).Set(float64(summary.DataAdded))
+			restic_session_processed_bytes.WithLabelValues(fgLabel).Set(float64(summary.TotalBytesProcessed))
+			restic_session_snapshot_info.WithLabelValues(fgLabel, summary.SnapshotID).Set(1)
+			fgStats = append(fgStats, api.FileGroupStats{
+				Path:                fg.Path,
+				SnapshotID:          summary.SnapshotID,
+				FilesNew:            summary.FilesNew,
+				FilesChanged:        summary.FilesChanged,
+				FilesUnmodified:     summary.FilesUnmodified,
+				DataAdded:           summary.DataAdded,
+				TotalBytesProcessed: summary.TotalBytesProcessed,
+				Duration:            time.Since(fgStartTime).String(),
+			})
+
 			hostname, _ := os.Hostname()
 			ref, rerr := reference.GetReference(scheme.Scheme, repository)
 			if rerr == nil {
@@ -342,38 +510,25 @@ func (c *Controller) runResticBackup(restic *api.Restic, repository *api.Reposit
 					ref,
 					core.EventTypeNormal,
 					eventer.EventReasonSuccessfulBackup,
-					fmt.Sprintf("Backed up pod: %s, path: %s", hostname, fg.Path),
+					fmt.Sprintf("Backed up pod: %s, path: %s, snapshot: %s, files new: %d, changed: %d, unmodified: %d, data added: %s, processed: %s",
+						hostname, fg.Path, summary.SnapshotID, summary.FilesNew, summary.FilesChanged, summary.FilesUnmodified,
+						util.FormatBytes(summary.DataAdded), util.FormatBytes(summary.TotalBytesProcessed)),
 				)
 			}
 		}
-
-		forgetOpMetric := restic_session_duration_seconds.WithLabelValues(sanitizeLabelValue(fg.Path), "forget")
-		err = c.measure(c.resticCLI.Forget, restic, fg, forgetOpMetric)
-		if err != nil {
-			log.Errorf("Failed to forget old snapshots for Repository %s/%s, reason: %s\n", repository.Namespace, repository.Name, err)
-			ref, rerr := reference.GetReference(scheme.Scheme, repository)
-			if rerr == nil {
-				eventer.CreateEventWithLog(
-					c.k8sClient,
-					BackupEventComponent,
-					ref,
-					core.EventTypeWarning,
-					eventer.EventReasonFailedToRetention,
-					fmt.Sprintf("Failed to forget old snapshots, reason: %s", err),
-				)
-			}
-			return
-		}
+	}
+	if len(failedPaths) > 0 {
+		err = fileGroupsError(failedPaths, fgErr)
 	}
 	return
 }
 
-func (c *Controller) measure(f func(*api.Restic, api.FileGroup) error, restic *api.Restic, fg api.FileGroup, g prometheus.Gauge) (err error) {
+func (c *Controller) measure(f func() error, g prometheus.Gauge) (err error) {
 	startTime := time.Now()
 	defer func() {
 		g.Set(time.Now().Sub(startTime).Seconds())
 	}()
-	err = f(restic, fg)
+	err = f()
 	return
 }
 
@@ -0,0 +1,73 @@
+package backup
+
+import (
+	"github.com/appscode/go/log"
+	api "github.com/appscode/stash/apis/stash/v1alpha1"
+	stash_util "github.com/appscode/stash/client/clientset/versioned/typed/stash/v1alpha1/util"
+	"github.com/appscode/stash/pkg/eventer"
+	core "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/reference"
+)
+
+func (c *Controller) initRepositoryWatcher() {
+	c.repoInformer = c.stashInformerFactory.Stash().V1alpha1().Repositories().Informer()
+	c.repoInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if r, ok := obj.(*api.Repository); ok {
+				c.handleBackupDispatch(r)
+			}
+		},
+		UpdateFunc: func(oldObj interface{}, newObj interface{}) {
+			if r, ok := newObj.(*api.Repository); ok {
+				c.handleBackupDispatch(r)
+			}
+		},
+	})
+}
+
+// handleBackupDispatch runs backup in background if operator dispatched a backup of the Repository of this workload
+// that is not completed yet. The dispatch is marked completed on the Repository when the backup is done or skipped.
+func (c *Controller) handleBackupDispatch(repository *api.Repository) {
+	if repository.Name != c.opt.Workload.GetRepositoryCRDName(c.opt.PodName, c.opt.NodeName) {
+		return
+	}
+	v := repository.Annotations[api.BackupDispatch]
+	if v == "" || v == repository.Annotations[api.BackupDispatchCompleted] || v == c.lastDispatch {
+		return
+	}
+	c.lastDispatch = v
+
+	go func() {
+		log.Infof("Running backup dispatched at %s for Repository %s/%s", v, repository.Namespace, repository.Name)
+		restic, err := c.rLister.Restics(c.opt.Namespace).Get(c.opt.ResticName)
+		if err != nil {
+			log.Errorln(err)
+		} else if !c.skipInBlackoutWindow(restic, "dispatched backup") {
+			err = c.runOnceForScheduler()
+			ref, rerr := reference.GetReference(scheme.Scheme, restic)
+			if err == errLocked {
+				if rerr == nil {
+					c.recorder.Eventf(ref, core.EventTypeWarning, eventer.EventReasonSkippedBackup, "Skipped dispatched backup of pod %s, previous backup is still running", c.opt.PodName)
+				}
+			} else if err != nil {
+				if rerr == nil {
+					c.recorder.Event(ref, core.EventTypeWarning, eventer.EventReasonFailedCronJob, err.Error())
+				}
+				log.Errorln(err)
+			}
+		}
+
+		_, _, err = stash_util.PatchRepository(c.stashClient.StashV1alpha1(), repository, func(in *api.Repository) *api.Repository {
+			if in.Annotations == nil {
+				in.Annotations = map[string]string{}
+			}
+			in.Annotations[api.BackupDispatchCompleted] = v
+			return in
+		})
+		if err != nil {
+			log.Errorf("Failed to complete backup dispatched at %s for Repository %s/%s, reason: %s\n", v, repository.Namespace, repository.Name, err)
+		}
+	}()
+}
@@ -0,0 +1,48 @@
+package backup
+
+import (
+	"fmt"
+
+	"github.com/appscode/go/log"
+	api "github.com/appscode/stash/apis/stash/v1alpha1"
+	"github.com/appscode/stash/pkg/eventer"
+	"github.com/pkg/errors"
+	core "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/reference"
+)
+
+// runHooks executes hooks of a stage in the pod of this sidecar and records results as events on repository.
+// Returns error of the first failed hook with Abort failure policy, remaining hooks are not executed.
+func (c *Controller) runHooks(hooks []api.Hook, repository *api.Repository, stage string) error {
+	for _, hook := range hooks {
+		output, err := c.hooks.Execute(hook, c.opt.Namespace, c.opt.PodName)
+
+		eventType, reason := core.EventTypeNormal, eventer.EventReasonSuccessfulHook
+		msg := fmt.Sprintf("%s hook %s succeeded for pod %s", stage, hook.Name, c.opt.PodName)
+		if err != nil {
+			log.Errorf("%s hook %s failed for Repository %s/%s, reason: %s\n", stage, hook.Name, repository.Namespace, repository.Name, err)
+			eventType, reason = core.EventTypeWarning, eventer.EventReasonFailedHook
+			msg = fmt.Sprintf("%s hook %s failed for pod %s, reason: %s", stage, hook.Name, c.opt.PodName, err)
+		}
+		if output != "" {
+			msg += ", output: " + output
+		}
+		ref, rerr := reference.GetReference(scheme.Scheme, repository)
+		if rerr == nil {
+			eventer.CreateEventWithLog(
+				c.k8sClient,
+				BackupEventComponent,
+				ref,
+				eventType,
+				reason,
+				msg,
+			)
+		}
+
+		if err != nil && hook.OnFailure != api.HookFailurePolicyContinue {
+			return errors.Wrapf(err, "%s hook %s failed", stage, hook.Name)
+		}
+	}
+	return nil
+}
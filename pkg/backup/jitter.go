@@ -0,0 +1,33 @@
+package backup
+
+import (
+	"hash/fnv"
+	"time"
+
+	"gopkg.in/robfig/cron.v2"
+)
+
+// jitterSchedule delays each activation of a schedule by a constant offset.
+type jitterSchedule struct {
+	cron.Schedule
+	offset time.Duration
+}
+
+func (s jitterSchedule) Next(t time.Time) time.Time {
+	if every, ok := s.Schedule.(cron.ConstantDelaySchedule); ok {
+		// @every is relative to the start of the sidecar, align it to wall clock so that the offset spreads sidecars
+		offset := s.offset % every.Delay
+		return t.Add(-offset).Truncate(every.Delay).Add(every.Delay + offset)
+	}
+	return s.Schedule.Next(t.Add(-s.offset)).Add(s.offset)
+}
+
+// jitterOffset returns a stable offset within window for hostname, truncated to seconds.
+func jitterOffset(hostname string, window time.Duration) time.Duration {
+	if window < time.Second {
+		return 0
+	}
+	h := fnv.New64a()
+	h.Write([]byte(hostname))
+	return time.Duration(h.Sum64()%uint64(window/time.Second)) * time.Second
+}
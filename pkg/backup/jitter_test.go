@@ -0,0 +1,80 @@
+package backup
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"gopkg.in/robfig/cron.v2"
+)
+
+func TestJitterOffset(t *testing.T) {
+	if offset := jitterOffset("stash-demo-0", 0); offset != 0 {
+		t.Errorf("expected no offset without window, got %s", offset)
+	}
+	if offset := jitterOffset("stash-demo-0", 500*time.Millisecond); offset != 0 {
+		t.Errorf("expected no offset for window below a second, got %s", offset)
+	}
+
+	window := time.Hour
+	offsets := map[time.Duration]bool{}
+	for i := 0; i < 100; i++ {
+		hostname := fmt.Sprintf("stash-demo-%d", i)
+		offset := jitterOffset(hostname, window)
+		if offset < 0 || offset >= window {
+			t.Errorf("%s: offset %s is not within %s", hostname, offset, window)
+		}
+		if offset%time.Second != 0 {
+			t.Errorf("%s: offset %s is not truncated to seconds", hostname, offset)
+		}
+		if again := jitterOffset(hostname, window); again != offset {
+			t.Errorf("%s: offset is not stable, got %s and %s", hostname, offset, again)
+		}
+		offsets[offset] = true
+	}
+	if len(offsets) < 90 {
+		t.Errorf("expected offsets of 100 hosts to be spread within %s, got %d distinct offsets", window, len(offsets))
+	}
+}
+
+func TestJitterSchedule(t *testing.T) {
+	at := func(hour, min, sec int) time.Time {
+		return time.Date(2018, 4, 10, hour, min, sec, 0, time.UTC)
+	}
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		schedule string
+		offset   time.Duration
+		t        time.Time
+		expected time.Time
+	}{
+		{"0 * * * *", 0, at(10, 5, 0), at(11, 0, 0)},
+		{"0 * * * *", 10 * time.Minute, at(10, 5, 0), at(10, 10, 0)},
+		{"0 * * * *", 10 * time.Minute, at(10, 9, 59), at(10, 10, 0)},
+		{"0 * * * *", 10 * time.Minute, at(10, 10, 0), at(11, 10, 0)},
+		{"0 * * * *", 10 * time.Minute, at(10, 59, 0), at(11, 10, 0)},
+		{"0 * * * *", 10*time.Minute + 30*time.Second, at(10, 5, 0), at(10, 10, 30)},
+		// constant delay schedules are aligned to wall clock
+		{"@every 1h", 15 * time.Minute, at(10, 20, 0), at(11, 15, 0)},
+		{"@every 1h", 15 * time.Minute, at(10, 14, 0), at(10, 15, 0)},
+		{"@every 1h", 15 * time.Minute, at(10, 15, 0), at(11, 15, 0)},
+		{"@every 10m", 25 * time.Minute, at(10, 0, 0), at(10, 5, 0)},
+		// schedule in time zone, 02:00 in Tokyo is 17:00 UTC
+		{"TZ=Asia/Tokyo 0 2 * * *", 30 * time.Minute, at(10, 0, 0), time.Date(2018, 4, 11, 2, 30, 0, 0, tokyo)},
+		{"TZ=Asia/Tokyo 0 2 * * *", 30 * time.Minute, at(17, 15, 0), time.Date(2018, 4, 11, 2, 30, 0, 0, tokyo)},
+		{"TZ=Asia/Tokyo 0 2 * * *", 30 * time.Minute, at(17, 30, 0), time.Date(2018, 4, 12, 2, 30, 0, 0, tokyo)},
+	}
+	for _, c := range cases {
+		schedule, err := cron.Parse(c.schedule)
+		if err != nil {
+			t.Fatalf("%s: %s", c.schedule, err)
+		}
+		s := jitterSchedule{Schedule: schedule, offset: c.offset}
+		if next := s.Next(c.t); !next.Equal(c.expected) {
+			t.Errorf("%s with offset %s after %s: expected %s, got %s", c.schedule, c.offset, c.t, c.expected.UTC(), next.UTC())
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/appscode/go/log"
+	api "github.com/appscode/stash/apis/stash/v1alpha1"
+	"github.com/appscode/stash/pkg/cli"
+	"github.com/appscode/stash/pkg/eventer"
+	"github.com/appscode/stash/pkg/util"
+	"github.com/pkg/errors"
+	"gopkg.in/robfig/cron.v2"
+	core "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/reference"
+)
+
+const (
+	DefaultRetryBackoff = 30 * time.Second
+
+	// Interval between backup attempts while the repository is locked, eg: by prune of the maintenance job
+	LockRetryInterval = time.Minute
+	// Maximum duration to wait for the repository lock, if limited neither by the timeout of the execution policy
+	// nor by the schedule of Restic
+	DefaultLockWaitTimeout = 30 * time.Minute
+)
+
+// lockWaitTimeout returns how long backup of restic waits for the repository lock. It is the timeout of
+// the execution policy, otherwise the interval of the schedule, so that a backup waiting for the lock does
+// not block the following scheduled backups.
+func lockWaitTimeout(restic *api.Restic, now time.Time) time.Duration {
+	if p := restic.Spec.ExecutionPolicy; p != nil && p.Timeout != nil && p.Timeout.Duration > 0 {
+		return p.Timeout.Duration
+	}
+	if schedule, err := cron.Parse(restic.Spec.CronSchedule()); err == nil {
+		if next := schedule.Next(now); !next.IsZero() {
+			if interval := schedule.Next(next).Sub(next); interval > 0 {
+				return interval
+			}
+		}
+	}
+	return DefaultLockWaitTimeout
+}
+
+// backupContext returns a context that is done after the timeout of policy.
+// The returned func must be called when the commands are done.
+func backupContext(policy *api.ExecutionPolicy) (context.Context, context.CancelFunc) {
+	if policy != nil && policy.Timeout != nil && policy.Timeout.Duration > 0 {
+		return context.WithTimeout(context.Background(), policy.Timeout.Duration)
+	}
+	return context.WithCancel(context.Background())
+}
+
+// backupFileGroup runs backup of fg, retrying transient failures as configured in the execution policy of restic.
+// Timeout of the execution policy limits all attempts together, including backoff between them.
+// While the repository is locked, backup waits for the lock without counting it as an attempt, see lockWaitTimeout.
+// Each failed attempt is recorded as event on repository.
+func (c *Controller) backupFileGroup(restic *api.Restic, repository *api.Repository, fg api.FileGroup, progress cli.ProgressFunc) (*cli.BackupSummary, error) {
+	policy := restic.Spec.ExecutionPolicy
+	attempts := 1
+	backoff := DefaultRetryBackoff
+	if policy != nil {
+		attempts += int(policy.Retries)
+		if policy.Backoff != nil && policy.Backoff.Duration > 0 {
+			backoff = policy.Backoff.Duration
+		}
+	}
+	ctx, cancel := backupContext(policy)
+	defer cancel()
+	ctxCLI := c.resticCLI.WithContext(ctx)
+	resticCLI := ctxCLI.WithProgress(progress)
+
+	lockWait := lockWaitTimeout(restic, time.Now())
+	lockDeadline := time.Now().Add(lockWait)
+	for attempt := 1; ; attempt++ {
+		summary, err := resticCLI.Backup(restic, fg)
+		if err == nil {
+			return summary, nil
+		}
+		// a lock left by a killed backup is removed, a lock of a running operation is waited for
+		if cli.ErrorTypeOf(err) == cli.ErrorRepositoryLocked {
+			if !time.Now().Before(lockDeadline) {
+				return nil, errors.Wrapf(err, "backup of path %s gave up waiting %s for repository lock", fg.Path, lockWait)
+			}
+			attempt--
+			if c.removeStaleLocks(ctxCLI, restic, repository) {
+				continue
+			}
+			log.Infof("Repository %s/%s is locked, retrying backup of path %s in %s", repository.Namespace, repository.Name, fg.Path, LockRetryInterval)
+			select {
+			case <-time.After(LockRetryInterval):
+				continue
+			case <-ctx.Done():
+				return nil, errors.Wrapf(err, "backup of path %s timed out while waiting for repository lock", fg.Path)
+			}
+		}
+
+		// no attempt is left before the deadline
+		retry := attempt < attempts && cli.IsTransient(err) && ctx.Err() == nil
+		msg := fmt.Sprintf("Backup of path %s failed on attempt %d of %d, reason: %s", fg.Path, attempt, attempts, err)
+		if retry {
+			msg = fmt.Sprintf("%s. Retrying in %s", msg, backoff)
+		}
+		log.Errorf("Backup failed for Repository %s/%s, reason: %s\n", repository.Namespace, repository.Name, msg)
+		ref, rerr := reference.GetReference(scheme.Scheme, repository)
+		if rerr == nil {
+			eventer.CreateEventWithLog(
+				c.k8sClient,
+				BackupEventComponent,
+				ref,
+				core.EventTypeWarning,
+				eventer.ReasonForError(err, eventer.EventReasonFailedToBackup),
+				msg,
+			)
+		}
+		if !retry {
+			return nil, err
+		}
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return nil, errors.Wrapf(err, "backup of path %s timed out while waiting to retry", fg.Path)
+		}
+		backoff *= 2
+	}
+}
+
+// removeStaleLocks removes stale locks of the repository and records them as event on repository.
+// Returns true if locks were removed.
+func (c *Controller) removeStaleLocks(resticCLI *cli.ResticWrapper, restic *api.Restic, repository *api.Repository) bool {
+	locks, err := util.RemoveStaleLocks(c.k8sClient, resticCLI, c.opt.Namespace, c.opt.Workload, restic.Spec.ExecutionPolicy)
+	if err != nil {
+		log.Errorf("Failed to remove stale locks of Repository %s/%s, reason: %s\n", repository.Namespace, repository.Name, err)
+		return false
+	}
+	if len(locks) == 0 {
+		return false
+	}
+	ref, rerr := reference.GetReference(scheme.Scheme, repository)
+	if rerr == nil {
+		eventer.CreateEventWithLog(
+			c.k8sClient,
+			BackupEventComponent,
+			ref,
+			core.EventTypeNormal,
+			eventer.EventReasonRepositoryUnlocked,
+			util.StaleLocksMessage(locks),
+		)
+	}
+	return true
+}
+
+// fileGroupsError returns error of a session where backup of paths failed. Cause of the error is
+// the last failure, so that its type is reported in metrics.
+func fileGroupsError(paths []string, last error) error {
+	return errors.Wrapf(last, "failed to backup paths %s", strings.Join(paths, ", "))
+}
@@ -0,0 +1,76 @@
+package backup
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	api "github.com/appscode/stash/apis/stash/v1alpha1"
+	"github.com/appscode/stash/pkg/cli"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestBackupContext(t *testing.T) {
+	cases := []struct {
+		name    string
+		policy  *api.ExecutionPolicy
+		timeout time.Duration
+	}{
+		{name: "no policy"},
+		{name: "no timeout", policy: &api.ExecutionPolicy{Retries: 3}},
+		{name: "zero timeout", policy: &api.ExecutionPolicy{Timeout: &metav1.Duration{}}},
+		{name: "timeout", policy: &api.ExecutionPolicy{Timeout: &metav1.Duration{Duration: time.Hour}, Retries: 3}, timeout: time.Hour},
+	}
+	for _, c := range cases {
+		start := time.Now()
+		ctx, cancel := backupContext(c.policy)
+		deadline, ok := ctx.Deadline()
+		if c.timeout == 0 && ok {
+			t.Errorf("%s: expected no deadline, got %s", c.name, deadline)
+		}
+		if c.timeout > 0 {
+			// one deadline for all attempts, it does not depend on the number of retries
+			if !ok || deadline.Before(start.Add(c.timeout)) || deadline.After(time.Now().Add(c.timeout)) {
+				t.Errorf("%s: expected deadline in %s, got %s (%v)", c.name, c.timeout, deadline.Sub(start), ok)
+			}
+		}
+		cancel()
+		if ctx.Err() == nil {
+			t.Errorf("%s: expected context to be done after cancel", c.name)
+		}
+	}
+}
+
+func TestFileGroupsError(t *testing.T) {
+	last := &cli.ResticError{Type: cli.ErrorBackendUnreachable, Command: "backup"}
+	err := fileGroupsError([]string{"/source/data", "/source/config"}, last)
+	if got := cli.ErrorTypeOf(err); got != cli.ErrorBackendUnreachable {
+		t.Errorf("expected error type %s, got %s", cli.ErrorBackendUnreachable, got)
+	}
+	if expected := "failed to backup paths /source/data, /source/config: "; !strings.HasPrefix(err.Error(), expected) {
+		t.Errorf("expected error to start with %q, got %q", expected, err.Error())
+	}
+}
+
+func TestLockWaitTimeout(t *testing.T) {
+	now := time.Date(2018, 4, 10, 10, 59, 0, 0, time.UTC)
+	cases := []struct {
+		name     string
+		spec     api.ResticSpec
+		expected time.Duration
+	}{
+		{"every", api.ResticSpec{Schedule: "@every 1h"}, time.Hour},
+		{"cron", api.ResticSpec{Schedule: "0 */6 * * *"}, 6 * time.Hour},
+		{"cron next run soon", api.ResticSpec{Schedule: "0 * * * *"}, time.Hour},
+		{"timeout", api.ResticSpec{Schedule: "@every 1h", ExecutionPolicy: &api.ExecutionPolicy{Timeout: &metav1.Duration{Duration: 2 * time.Hour}}}, 2 * time.Hour},
+		{"zero timeout", api.ResticSpec{Schedule: "@every 1h", ExecutionPolicy: &api.ExecutionPolicy{Timeout: &metav1.Duration{}}}, time.Hour},
+		{"invalid schedule", api.ResticSpec{Schedule: "every hour"}, DefaultLockWaitTimeout},
+	}
+	for _, c := range cases {
+		restic := &api.Restic{Spec: c.spec}
+		restic.Spec.TimeZone = "UTC"
+		if got := lockWaitTimeout(restic, now); got != c.expected {
+			t.Errorf("%s: expected %s, got %s", c.name, c.expected, got)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/appscode/go/log"
 	api "github.com/appscode/stash/apis/stash/v1alpha1"
 	"github.com/appscode/stash/client/clientset/versioned/typed/stash/v1alpha1/util"
+	"github.com/appscode/stash/pkg/cli"
 )
 
 func (c *Controller) createRepositoryCrdIfNotExist(restic *api.Restic, prefix string) (*api.Repository, error) {
@@ -25,22 +26,20 @@ func (c *Controller) createRepositoryCrdIfNotExist(restic *api.Restic, prefix st
 	}
 
 	repository.Spec.Backend = *restic.Spec.Backend.DeepCopy()
-	if repository.Spec.Backend.Local != nil {
-		repository.Spec.Backend.Local.SubPath = prefix
-	} else if repository.Spec.Backend.Azure != nil {
-		repository.Spec.Backend.Azure.Prefix = prefix
-	} else if repository.Spec.Backend.B2 != nil {
-		repository.Spec.Backend.B2.Prefix = prefix
-	} else if repository.Spec.Backend.GCS != nil {
-		repository.Spec.Backend.GCS.Prefix = prefix
-	} else if repository.Spec.Backend.S3 != nil {
-		repository.Spec.Backend.S3.Prefix = prefix
-	} else if repository.Spec.Backend.Swift != nil {
-		repository.Spec.Backend.Swift.Prefix = prefix
+	provider, err := cli.GetBackendProvider(&repository.Spec.Backend)
+	if err != nil {
+		return nil, err
 	}
+	provider.SetPrefix(&repository.Spec.Backend, prefix)
 
 	repo, _, err := util.CreateOrPatchRepository(c.stashClient.StashV1alpha1(), repository.ObjectMeta, func(in *api.Repository) *api.Repository {
 		in.Spec = repository.Spec
+		if c.opt.NodeName != "" {
+			if in.Annotations == nil {
+				in.Annotations = map[string]string{}
+			}
+			in.Annotations[api.NodeName] = c.opt.NodeName
+		}
 		return in
 	})
 	if err == nil {
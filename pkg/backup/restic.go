@@ -19,18 +19,16 @@ func (c *Controller) initResticWatcher() {
 
 	c.rInformer = c.stashInformerFactory.Stash().V1alpha1().Restics().Informer()
 	c.rQueue = queue.New("Restic", c.opt.MaxNumRequeues, c.opt.NumThreads, c.runResticScheduler)
+	c.tQueue = queue.New("BackupTrigger", c.opt.MaxNumRequeues, c.opt.NumThreads, c.runBackupTrigger)
 	c.rInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc: func(obj interface{}) {
 			if r, ok := obj.(*api.Restic); ok && r.Name == c.opt.ResticName && r.IsValid() == nil {
 				queue.Enqueue(c.rQueue.GetQueue(), r)
+				queue.Enqueue(c.tQueue.GetQueue(), r)
 			}
 		},
 		UpdateFunc: func(oldObj interface{}, newObj interface{}) {
-			old := oldObj.(*api.Restic)
-			nu := newObj.(*api.Restic)
-			if !util.ResticEqual(old, nu) && nu.Name == c.opt.ResticName && nu.IsValid() == nil {
-				queue.Enqueue(c.rQueue.GetQueue(), nu)
-			}
+			c.updateRestic(oldObj.(*api.Restic), newObj.(*api.Restic))
 		},
 		DeleteFunc: func(obj interface{}) {
 			// IndexerInformer uses a delta queue, therefore for deletes we have to use this
@@ -43,6 +41,20 @@ func (c *Controller) initResticWatcher() {
 	c.rLister = c.stashInformerFactory.Stash().V1alpha1().Restics().Lister()
 }
 
+// updateRestic configures the scheduler again if spec of Restic changed. A changed backup trigger is handled
+// separately, so that a triggered backup keeps the cron entries with their jitter and queued missed runs.
+func (c *Controller) updateRestic(old, nu *api.Restic) {
+	if nu.Name != c.opt.ResticName || nu.IsValid() != nil {
+		return
+	}
+	if !util.ResticEqual(old, nu) {
+		queue.Enqueue(c.rQueue.GetQueue(), nu)
+	}
+	if old.Annotations[api.BackupTrigger] != nu.Annotations[api.BackupTrigger] {
+		queue.Enqueue(c.tQueue.GetQueue(), nu)
+	}
+}
+
 // syncToStdout is the business logic of the controller. In this controller it simply prints
 // information about the deployment to stdout. In case an error happened, it has to simply return the error.
 // The retry logic should not be part of the business logic.
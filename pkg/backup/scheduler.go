@@ -7,10 +7,12 @@ import (
 	"github.com/appscode/go/log"
 	api "github.com/appscode/stash/apis/stash/v1alpha1"
 	"github.com/appscode/stash/client/clientset/versioned/scheme"
+	"github.com/appscode/stash/pkg/cli"
 	"github.com/appscode/stash/pkg/eventer"
 	"github.com/appscode/stash/pkg/util"
 	"github.com/golang/glog"
 	"github.com/pkg/errors"
+	"gopkg.in/robfig/cron.v2"
 	core "k8s.io/api/core/v1"
 	kerr "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -24,6 +26,9 @@ const (
 	LeaderElectionLease = 3 * time.Second
 )
 
+// errLocked is returned when backup is skipped because another backup or check is running
+var errLocked = errors.New("another backup or check is running")
+
 func (c *Controller) BackupScheduler() error {
 	stopBackup := make(chan struct{})
 	defer close(stopBackup)
@@ -61,6 +66,7 @@ func (c *Controller) setupAndRunScheduler(stopBackup chan struct{}) error {
 		return err
 	}
 	c.initResticWatcher() // setup restic watcher, not required for offline backup
+	c.initRepositoryWatcher()
 	go c.runScheduler(stopBackup)
 	return nil
 }
@@ -109,6 +115,8 @@ func (c *Controller) runScheduler(stopCh chan struct{}) {
 	}
 
 	c.rQueue.Run(stopCh)
+	c.tQueue.Run(stopCh)
+	go c.backupOnStart()
 
 	<-stopCh
 	glog.Info("Stopping Stash backup")
@@ -119,32 +127,118 @@ func (c *Controller) configureScheduler(r *api.Restic) error {
 	for _, v := range c.cron.Entries() {
 		c.cron.Remove(v.ID)
 	}
-	_, err := c.cron.AddFunc(r.Spec.Schedule, func() {
-		if err := c.runOnceForScheduler(); err != nil {
-			ref, rerr := reference.GetReference(scheme.Scheme, r)
-			if rerr == nil {
-				c.recorder.Event(ref, core.EventTypeWarning, eventer.EventReasonFailedCronJob, err.Error())
-			}
-			log.Errorln(err)
-		}
-	})
+	schedule, err := cron.Parse(r.Spec.CronSchedule())
 	if err != nil {
 		return err
 	}
+	if r.Spec.Jitter != nil && r.Spec.Jitter.Duration > 0 {
+		offset := jitterOffset(c.opt.SnapshotHostname, r.Spec.Jitter.Duration)
+		log.Infof("Delaying scheduled backups of host %s by %s", c.opt.SnapshotHostname, offset)
+		schedule = jitterSchedule{Schedule: schedule, offset: offset}
+	}
+	// backups of Restic scheduled by operator are dispatched via Repository
+	if r.Spec.Scheduler != api.SchedulerOperator {
+		c.cron.Schedule(schedule, cron.FuncJob(c.runScheduledBackup))
+	}
 	_, err = c.cron.AddFunc("0 0 */3 * *", func() { c.checkOnceForScheduler() })
 	return err
 }
 
+// runScheduledBackup is run by cron. If another backup or check is running,
+// the run is skipped or queued according to the missed run policy of Restic.
+func (c *Controller) runScheduledBackup() {
+	restic, err := c.rLister.Restics(c.opt.Namespace).Get(c.opt.ResticName)
+	if err != nil {
+		log.Errorln(err)
+		return
+	}
+	if c.skipInBlackoutWindow(restic, "scheduled backup") {
+		return
+	}
+
+	err = c.runOnceForScheduler()
+	if err == nil {
+		return
+	}
+	ref, rerr := reference.GetReference(scheme.Scheme, restic)
+	if err == errLocked {
+		if c.queueMissedRun(restic.Spec.MissedRunPolicy) {
+			log.Infof("Queued scheduled backup for Restic %s/%s until running backup completes", restic.Namespace, restic.Name)
+		} else if rerr == nil {
+			c.recorder.Eventf(ref, core.EventTypeWarning, eventer.EventReasonSkippedBackup, "Skipped scheduled backup of pod %s, previous backup is still running", c.opt.PodName)
+		}
+		return
+	}
+	if rerr == nil {
+		c.recorder.Event(ref, core.EventTypeWarning, eventer.EventReasonFailedCronJob, err.Error())
+	}
+	log.Errorln(err)
+}
+
+// skipInBlackoutWindow returns true and records an event on restic if restic is in a blackout window now.
+func (c *Controller) skipInBlackoutWindow(restic *api.Restic, run string) bool {
+	w, err := restic.Spec.ActiveBlackoutWindow(time.Now())
+	if err != nil {
+		log.Errorf("Failed to check blackout windows of Restic %s/%s, reason: %s\n", restic.Namespace, restic.Name, err)
+		return false
+	}
+	if w == nil {
+		return false
+	}
+	ref, rerr := reference.GetReference(scheme.Scheme, restic)
+	if rerr == nil {
+		eventer.CreateEventWithLog(
+			c.k8sClient,
+			BackupEventComponent,
+			ref,
+			core.EventTypeNormal,
+			eventer.EventReasonSkippedBackup,
+			fmt.Sprintf("Skipped %s of pod %s during blackout window %s", run, c.opt.PodName, w),
+		)
+	}
+	return true
+}
+
+// queueMissedRun records a scheduled backup missed because the lock was held.
+// It returns false if the missed run is skipped.
+func (c *Controller) queueMissedRun(policy api.MissedRunPolicy) bool {
+	c.pendingMu.Lock()
+	defer c.pendingMu.Unlock()
+
+	switch policy {
+	case api.MissedRunPolicyQueueOne:
+		c.pendingRuns = 1
+	case api.MissedRunPolicyCatchUp:
+		c.pendingRuns++
+	default:
+		return false
+	}
+	return true
+}
+
+// unlock releases the lock held by a backup or check and starts a queued scheduled backup, if any.
+func (c *Controller) unlock() {
+	c.locked <- struct{}{}
+
+	c.pendingMu.Lock()
+	pending := c.pendingRuns > 0
+	if pending {
+		c.pendingRuns--
+	}
+	c.pendingMu.Unlock()
+	if pending {
+		go c.runScheduledBackup()
+	}
+}
+
 func (c *Controller) runOnceForScheduler() error {
 	select {
 	case <-c.locked:
 		log.Infof("Acquired lock for Restic %s/%s", c.opt.Namespace, c.opt.ResticName)
-		defer func() {
-			c.locked <- struct{}{}
-		}()
+		defer c.unlock()
 	default:
 		log.Warningf("Skipping backup schedule for Restic %s/%s", c.opt.Namespace, c.opt.ResticName)
-		return nil
+		return errLocked
 	}
 
 	// check restic again, previously done in setup()
@@ -154,29 +248,80 @@ func (c *Controller) runOnceForScheduler() error {
 	} else if err != nil {
 		return err
 	}
+	repository, err := c.setupRepository(restic)
+	if err != nil {
+		return err
+	}
+
+	// run final restic backup command
+	return c.runResticBackup(restic, repository)
+}
+
+// backupOnStart runs backup when the sidecar starts, if requested by Restic and
+// the repository has no snapshot of this host yet.
+func (c *Controller) backupOnStart() {
+	restic, err := c.rLister.Restics(c.opt.Namespace).Get(c.opt.ResticName)
+	if err != nil {
+		log.Errorln(err)
+		return
+	}
+	if !restic.Spec.BackupOnStart || restic.Spec.Paused || restic.Spec.Scheduler == api.SchedulerOperator {
+		return
+	}
+	if c.skipInBlackoutWindow(restic, "backup on start") {
+		return
+	}
+
+	select {
+	case <-c.locked:
+		defer c.unlock()
+	default:
+		log.Infof("Skipping backup on start for Restic %s/%s, another backup is running", restic.Namespace, restic.Name)
+		return
+	}
+
+	repository, err := c.setupRepository(restic)
+	if err == nil {
+		var snapshots []cli.Snapshot
+		if snapshots, err = c.resticCLI.ListSnapshots(nil); err == nil {
+			for _, snapshot := range snapshots {
+				if snapshot.Hostname == c.opt.SnapshotHostname {
+					log.Infof("Skipping backup on start for Restic %s/%s, snapshot %s of host %s exists", restic.Namespace, restic.Name, snapshot.ID, snapshot.Hostname)
+					return
+				}
+			}
+			log.Infof("Running backup on start for Restic %s/%s, no snapshot of host %s exists", restic.Namespace, restic.Name, c.opt.SnapshotHostname)
+			err = c.runResticBackup(restic, repository)
+		}
+	}
+	if err != nil {
+		ref, rerr := reference.GetReference(scheme.Scheme, restic)
+		if rerr == nil {
+			c.recorder.Eventf(ref, core.EventTypeWarning, eventer.ReasonForError(err, eventer.EventReasonFailedToBackup), "Backup on start failed for pod %s, reason: %s", c.opt.PodName, err)
+		}
+		log.Errorln(err)
+	}
+}
+
+// setupRepository sets up restic for the backend of restic and ensures the repository and its CRD exist.
+func (c *Controller) setupRepository(restic *api.Restic) (*api.Repository, error) {
 	if restic.Spec.Backend.StorageSecretName == "" {
-		return errors.New("missing repository secret name")
+		return nil, errors.New("missing repository secret name")
 	}
 	secret, err := c.k8sClient.CoreV1().Secrets(restic.Namespace).Get(restic.Spec.Backend.StorageSecretName, metav1.GetOptions{})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// setup restic again, previously done in setup()
 	prefix := ""
 	if prefix, err = c.resticCLI.SetupEnv(restic.Spec.Backend, secret, c.opt.SmartPrefix); err != nil {
-		return err
+		return nil, err
 	}
 	if err = c.resticCLI.InitRepositoryIfAbsent(); err != nil {
-		return err
-	}
-	repository, err := c.createRepositoryCrdIfNotExist(restic, prefix)
-	if err != nil {
-		return err
+		return nil, err
 	}
-
-	// run final restic backup command
-	return c.runResticBackup(restic, repository)
+	return c.createRepositoryCrdIfNotExist(restic, prefix)
 }
 
 func (c *Controller) checkOnceForScheduler() (err error) {
@@ -193,22 +338,25 @@ func (c *Controller) checkOnceForScheduler() (err error) {
 	select {
 	case <-c.locked:
 		log.Infof("Acquired lock for Repository %s/%s", repository.Namespace, repository.Name)
-		defer func() {
-			c.locked <- struct{}{}
-		}()
+		defer c.unlock()
 	default:
 		log.Warningf("Skipping checkup schedule for Repository %s/%s", repository.Namespace, repository.Name)
 		return
 	}
 
 	err = c.resticCLI.Check()
+	if cli.ErrorTypeOf(err) == cli.ErrorRepositoryLocked {
+		if restic, rerr := c.rLister.Restics(c.opt.Namespace).Get(c.opt.ResticName); rerr == nil && c.removeStaleLocks(c.resticCLI, restic, repository) {
+			err = c.resticCLI.Check()
+		}
+	}
 	if err != nil {
 		ref, rerr := reference.GetReference(scheme.Scheme, repository)
 		if rerr == nil {
 			c.recorder.Eventf(
 				ref,
 				core.EventTypeWarning,
-				eventer.EventReasonFailedToCheck,
+				eventer.ReasonForError(err, eventer.EventReasonFailedToCheck),
 				"Repository check failed for workload %s %s/%s. Reason: %v",
 				c.opt.Workload.Kind, c.opt.Namespace, c.opt.Workload.Name, err)
 		}
@@ -0,0 +1,117 @@
+package backup
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/appscode/go/log"
+	core_util "github.com/appscode/kutil/core/v1"
+	api "github.com/appscode/stash/apis/stash/v1alpha1"
+	stash_util "github.com/appscode/stash/client/clientset/versioned/typed/stash/v1alpha1/util"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/reference"
+)
+
+const (
+	DefaultBackupHistoryLimit = 10
+)
+
+func backupHistoryLimit(restic *api.Restic) int {
+	if restic.Spec.BackupHistoryLimit != nil {
+		return int(*restic.Spec.BackupHistoryLimit)
+	}
+	return DefaultBackupHistoryLimit
+}
+
+// createBackupSession records start of a backup session for repository.
+// Returns nil if sessions are disabled or the session could not be created, backup is not affected.
+func (c *Controller) createBackupSession(restic *api.Restic, repository *api.Repository, startTime metav1.Time) *api.BackupSession {
+	if backupHistoryLimit(restic) <= 0 {
+		return nil
+	}
+
+	meta := metav1.ObjectMeta{
+		Name:      fmt.Sprintf("%s-%d", repository.Name, startTime.Unix()),
+		Namespace: repository.Namespace,
+		Labels:    map[string]string{},
+	}
+	for k, v := range repository.Labels {
+		meta.Labels[k] = v
+	}
+	// set after labels of repository, as sessions of repository are selected by it when history is pruned
+	meta.Labels["repository"] = repository.Name
+	ref, err := reference.GetReference(scheme.Scheme, repository)
+	if err != nil {
+		log.Errorln(err)
+		return nil
+	}
+
+	session, _, err := stash_util.CreateOrPatchBackupSession(c.stashClient.StashV1alpha1(), meta, func(in *api.BackupSession) *api.BackupSession {
+		in.ObjectMeta = core_util.EnsureOwnerReference(in.ObjectMeta, ref)
+		in.Spec = api.BackupSessionSpec{
+			Restic:     restic.Name,
+			Repository: repository.Name,
+			Workload:   c.opt.Workload,
+			PodName:    c.opt.PodName,
+			NodeName:   c.opt.NodeName,
+			Hostname:   c.opt.SnapshotHostname,
+		}
+		in.Status = api.BackupSessionStatus{
+			Phase:     api.BackupSessionRunning,
+			StartTime: &startTime,
+		}
+		return in
+	})
+	if err != nil {
+		log.Errorf("Failed to create BackupSession %s/%s, reason: %s\n", meta.Namespace, meta.Name, err)
+		return nil
+	}
+	return session
+}
+
+// completeBackupSession records result of session and deletes sessions of the repository exceeding history limit of restic.
+func (c *Controller) completeBackupSession(restic *api.Restic, session *api.BackupSession, stats []api.FileGroupStats, err error) {
+	if session == nil {
+		return
+	}
+
+	_, _, perr := stash_util.PatchBackupSession(c.stashClient.StashV1alpha1(), session, func(in *api.BackupSession) *api.BackupSession {
+		now := metav1.Now()
+		in.Status.CompletionTime = &now
+		in.Status.FileGroups = stats
+		if err != nil {
+			in.Status.Phase = api.BackupSessionFailed
+			in.Status.Error = err.Error()
+		} else {
+			in.Status.Phase = api.BackupSessionSucceeded
+		}
+		return in
+	})
+	if perr != nil {
+		// history is pruned only after this session is recorded as completed
+		log.Errorf("Failed to update BackupSession %s/%s, reason: %s\n", session.Namespace, session.Name, perr)
+		return
+	}
+
+	sessions, lerr := c.stashClient.StashV1alpha1().BackupSessions(session.Namespace).List(metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(map[string]string{"repository": session.Spec.Repository}).String(),
+	})
+	if lerr != nil {
+		log.Errorf("Failed to list BackupSessions of Repository %s/%s, reason: %s\n", session.Namespace, session.Spec.Repository, lerr)
+		return
+	}
+	items := sessions.Items
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].CreationTimestamp.Before(&items[j].CreationTimestamp)
+	})
+	for i := 0; i < len(items)-backupHistoryLimit(restic); i++ {
+		if items[i].Status.Phase == api.BackupSessionRunning {
+			continue
+		}
+		if derr := c.stashClient.StashV1alpha1().BackupSessions(items[i].Namespace).Delete(items[i].Name, &metav1.DeleteOptions{}); derr != nil {
+			log.Errorf("Failed to delete BackupSession %s/%s, reason: %s\n", items[i].Namespace, items[i].Name, derr)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package backup
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	api "github.com/appscode/stash/apis/stash/v1alpha1"
+	"github.com/appscode/stash/client/clientset/versioned/fake"
+	"github.com/appscode/stash/client/clientset/versioned/scheme"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientsetscheme "k8s.io/client-go/kubernetes/scheme"
+	clientgotesting "k8s.io/client-go/testing"
+)
+
+func init() {
+	// sessions are owned by Repository, as in the sidecar
+	scheme.AddToScheme(clientsetscheme.Scheme)
+}
+
+func sessionTestObjects(limit int32) (*api.Restic, *api.Repository) {
+	restic := &api.Restic{
+		ObjectMeta: metav1.ObjectMeta{Name: "stash-demo", Namespace: "default"},
+		Spec:       api.ResticSpec{BackupHistoryLimit: &limit},
+	}
+	repository := &api.Repository{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "deployment.stash-demo",
+			Namespace: "default",
+			SelfLink:  "/apis/stash.appscode.com/v1alpha1/namespaces/default/repositories/deployment.stash-demo",
+			Labels:    map[string]string{"restic": "stash-demo", "repository": "other"},
+		},
+	}
+	return restic, repository
+}
+
+func completedSessions(repository string, n int) []runtime.Object {
+	var objects []runtime.Object
+	for i := 0; i < n; i++ {
+		objects = append(objects, &api.BackupSession{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              fmt.Sprintf("%s-%d", repository, i),
+				Namespace:         "default",
+				Labels:            map[string]string{"repository": repository},
+				CreationTimestamp: metav1.NewTime(time.Date(2018, 4, 10, i, 0, 0, 0, time.UTC)),
+			},
+			Status: api.BackupSessionStatus{Phase: api.BackupSessionSucceeded},
+		})
+	}
+	return objects
+}
+
+func TestBackupSessionHistory(t *testing.T) {
+	restic, repository := sessionTestObjects(2)
+	client := fake.NewSimpleClientset(completedSessions(repository.Name, 3)...)
+	// fake clientset does not set creation timestamp, sessions are pruned in order of it
+	client.PrependReactor("create", "backupsessions", func(action clientgotesting.Action) (bool, runtime.Object, error) {
+		session := action.(clientgotesting.CreateAction).GetObject().(*api.BackupSession)
+		session.CreationTimestamp = *session.Status.StartTime
+		return false, nil, nil
+	})
+	c := &Controller{stashClient: client}
+
+	session := c.createBackupSession(restic, repository, metav1.NewTime(time.Date(2018, 4, 10, 10, 0, 0, 0, time.UTC)))
+	if session == nil {
+		t.Fatal("expected session to be created")
+	}
+	if v := session.Labels["repository"]; v != repository.Name {
+		t.Errorf("expected repository label %s, got %s", repository.Name, v)
+	}
+	if v := session.Labels["restic"]; v != restic.Name {
+		t.Errorf("expected restic label %s, got %s", restic.Name, v)
+	}
+
+	c.completeBackupSession(restic, session, nil, nil)
+	sessions, err := client.StashV1alpha1().BackupSessions("default").List(metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, item := range sessions.Items {
+		names = append(names, item.Name)
+	}
+	sort.Strings(names)
+	if expected := []string{"deployment.stash-demo-1523354400", "deployment.stash-demo-2"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected sessions %v after pruning, got %v", expected, names)
+	}
+}
+
+func TestBackupSessionHistoryNotPrunedOnPatchError(t *testing.T) {
+	restic, repository := sessionTestObjects(2)
+	client := fake.NewSimpleClientset(completedSessions(repository.Name, 3)...)
+	c := &Controller{stashClient: client}
+
+	session := c.createBackupSession(restic, repository, metav1.NewTime(time.Date(2018, 4, 10, 10, 0, 0, 0, time.UTC)))
+	if session == nil {
+		t.Fatal("expected session to be created")
+	}
+	client.PrependReactor("patch", "backupsessions", func(action clientgotesting.Action) (bool, runtime.Object, error) {
+		return true, nil, fmt.Errorf("conflict")
+	})
+	c.completeBackupSession(restic, session, nil, nil)
+	for _, action := range client.Actions() {
+		if action.GetVerb() == "delete" {
+			t.Errorf("expected no session to be deleted, got delete of %s", action.(clientgotesting.DeleteAction).GetName())
+		}
+	}
+}
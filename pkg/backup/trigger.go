@@ -0,0 +1,87 @@
+package backup
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/appscode/go/log"
+	api "github.com/appscode/stash/apis/stash/v1alpha1"
+	"github.com/appscode/stash/pkg/eventer"
+	"github.com/golang/glog"
+	core "k8s.io/api/core/v1"
+	kerr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/reference"
+)
+
+// runBackupTrigger handles the backup trigger of Restic with key. Unlike runResticScheduler, it does not configure
+// the scheduler again.
+func (c *Controller) runBackupTrigger(key string) error {
+	obj, exists, err := c.rInformer.GetIndexer().GetByKey(key)
+	if err != nil {
+		glog.Errorf("Fetching object with key %s from store failed with %v", key, err)
+		return err
+	}
+	if exists {
+		c.handleBackupTrigger(obj.(*api.Restic))
+	}
+	return nil
+}
+
+// handleBackupTrigger runs backup in background if restic has a backup trigger newer than
+// the last handled trigger and the last backup of this workload. Result is recorded as event on restic.
+func (c *Controller) handleBackupTrigger(restic *api.Restic) {
+	v, ok := restic.Annotations[api.BackupTrigger]
+	if !ok {
+		return
+	}
+	requestTime, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		c.recordTriggerEvent(restic, core.EventTypeWarning, eventer.EventReasonFailedTriggeredBackup,
+			fmt.Sprintf("Invalid backup trigger %s, reason: %s", v, err))
+		return
+	}
+	if !requestTime.After(c.lastTrigger) {
+		return
+	}
+	c.lastTrigger = requestTime
+
+	// ignore triggers already handled by a backup, eg: before sidecar restarted
+	repository, err := c.stashClient.StashV1alpha1().Repositories(c.opt.Namespace).Get(c.opt.Workload.GetRepositoryCRDName(c.opt.PodName, c.opt.NodeName), metav1.GetOptions{})
+	if err != nil && !kerr.IsNotFound(err) {
+		log.Errorln(err)
+	} else if err == nil && repository.Status.LastBackupTime != nil && !requestTime.After(repository.Status.LastBackupTime.Time) {
+		log.Infof("Ignoring backup trigger %s, last backup was taken at %s", v, repository.Status.LastBackupTime)
+		return
+	}
+
+	go func() {
+		log.Infof("Running backup requested at %s for Restic %s/%s", v, restic.Namespace, restic.Name)
+		switch err := c.runOnceForScheduler(); err {
+		case nil:
+			c.recordTriggerEvent(restic, core.EventTypeNormal, eventer.EventReasonSuccessfulTriggeredBackup,
+				fmt.Sprintf("Backup requested at %s succeeded for pod %s", v, c.opt.PodName))
+		case errLocked:
+			c.recordTriggerEvent(restic, core.EventTypeWarning, eventer.EventReasonSkippedTriggeredBackup,
+				fmt.Sprintf("Backup requested at %s skipped for pod %s, reason: %s", v, c.opt.PodName, err))
+		default:
+			c.recordTriggerEvent(restic, core.EventTypeWarning, eventer.EventReasonFailedTriggeredBackup,
+				fmt.Sprintf("Backup requested at %s failed for pod %s, reason: %s", v, c.opt.PodName, err))
+		}
+	}()
+}
+
+func (c *Controller) recordTriggerEvent(restic *api.Restic, eventType, reason, msg string) {
+	ref, rerr := reference.GetReference(scheme.Scheme, restic)
+	if rerr == nil {
+		eventer.CreateEventWithLog(
+			c.k8sClient,
+			BackupEventComponent,
+			ref,
+			eventType,
+			reason,
+			msg,
+		)
+	}
+}
@@ -0,0 +1,101 @@
+package backup
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/appscode/kutil/tools/queue"
+	api "github.com/appscode/stash/apis/stash/v1alpha1"
+	"github.com/appscode/stash/client/clientset/versioned/fake"
+	"gopkg.in/robfig/cron.v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+)
+
+func cronEntryIDs(c *cron.Cron) []cron.EntryID {
+	var ids []cron.EntryID
+	for _, entry := range c.Entries() {
+		ids = append(ids, entry.ID)
+	}
+	return ids
+}
+
+func TestBackupTriggerKeepsCronEntries(t *testing.T) {
+	trigger := time.Date(2018, 4, 10, 10, 0, 0, 0, time.UTC)
+	restic := &api.Restic{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "stash-demo",
+			Namespace: "default",
+			SelfLink:  "/apis/stash.appscode.com/v1alpha1/namespaces/default/restics/stash-demo",
+		},
+		Spec: api.ResticSpec{
+			Schedule: "@every 1h",
+			Jitter:   &metav1.Duration{Duration: 10 * time.Minute},
+			Backend:  api.Backend{StorageSecretName: "s3-secret"},
+		},
+	}
+	// the trigger was handled by a backup already, so that no backup is run
+	repository := &api.Repository{
+		ObjectMeta: metav1.ObjectMeta{Name: "deployment.stash-demo", Namespace: "default"},
+		Status:     api.RepositoryStatus{LastBackupTime: &metav1.Time{Time: trigger.Add(time.Minute)}},
+	}
+
+	c := &Controller{
+		k8sClient:   kfake.NewSimpleClientset(),
+		stashClient: fake.NewSimpleClientset(repository),
+		opt: Options{
+			Namespace:        "default",
+			ResticName:       "stash-demo",
+			SnapshotHostname: "stash-demo",
+			Workload:         api.LocalTypedReference{Kind: api.KindDeployment, Name: "stash-demo"},
+		},
+		cron:      cron.New(),
+		rInformer: cache.NewSharedIndexInformer(&cache.ListWatch{}, &api.Restic{}, 0, cache.Indexers{}),
+	}
+	c.rQueue = queue.New("Restic", 0, 1, c.runResticScheduler)
+	c.tQueue = queue.New("BackupTrigger", 0, 1, c.runBackupTrigger)
+	defer c.rQueue.GetQueue().ShutDown()
+	defer c.tQueue.GetQueue().ShutDown()
+
+	if err := c.rInformer.GetIndexer().Add(restic); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.runResticScheduler("default/stash-demo"); err != nil {
+		t.Fatal(err)
+	}
+	entries := cronEntryIDs(c.cron)
+	if len(entries) != 2 {
+		t.Fatalf("expected cron entries of backup and check, got %v", entries)
+	}
+
+	triggered := restic.DeepCopy()
+	triggered.Annotations = map[string]string{api.BackupTrigger: trigger.Format(time.RFC3339)}
+	c.updateRestic(restic, triggered)
+	if n := c.rQueue.GetQueue().Len(); n != 0 {
+		t.Errorf("expected trigger not to configure scheduler, got %d queued", n)
+	}
+	if n := c.tQueue.GetQueue().Len(); n != 1 {
+		t.Errorf("expected trigger to be queued, got %d queued", n)
+	}
+	if err := c.rInformer.GetIndexer().Update(triggered); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.runBackupTrigger("default/stash-demo"); err != nil {
+		t.Fatal(err)
+	}
+	if !c.lastTrigger.Equal(trigger) {
+		t.Errorf("expected trigger %s to be handled, got %s", trigger, c.lastTrigger)
+	}
+	if got := cronEntryIDs(c.cron); !reflect.DeepEqual(got, entries) {
+		t.Errorf("expected cron entries %v to be kept, got %v", entries, got)
+	}
+
+	changed := triggered.DeepCopy()
+	changed.Spec.Schedule = "@every 2h"
+	c.updateRestic(triggered, changed)
+	if n := c.rQueue.GetQueue().Len(); n != 1 {
+		t.Errorf("expected spec update to configure scheduler, got %d queued", n)
+	}
+}
@@ -3,9 +3,12 @@ package check
 import (
 	"fmt"
 
+	"github.com/appscode/go/log"
+	api "github.com/appscode/stash/apis/stash/v1alpha1"
 	cs "github.com/appscode/stash/client/clientset/versioned/typed/stash/v1alpha1"
 	"github.com/appscode/stash/pkg/cli"
 	"github.com/appscode/stash/pkg/eventer"
+	"github.com/appscode/stash/pkg/util"
 	core "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
@@ -18,10 +21,12 @@ const (
 )
 
 type Options struct {
-	Namespace   string
-	ResticName  string
-	HostName    string
-	SmartPrefix string
+	Namespace      string
+	ResticName     string
+	Workload       api.LocalTypedReference
+	HostName       string
+	SmartPrefix    string
+	PushgatewayURL string
 }
 
 type Controller struct {
@@ -45,6 +50,9 @@ func (c *Controller) Run() (err error) {
 	}
 
 	defer func() {
+		if c.opt.PushgatewayURL != "" {
+			c.pushMetrics(restic, err)
+		}
 		if err != nil {
 			ref, rerr := reference.GetReference(scheme.Scheme, restic)
 			if rerr == nil {
@@ -53,7 +61,7 @@ func (c *Controller) Run() (err error) {
 					CheckEventComponent,
 					ref,
 					core.EventTypeWarning,
-					eventer.EventReasonFailedToCheck,
+					eventer.ReasonForError(err, eventer.EventReasonFailedToCheck),
 					fmt.Sprintf("Check failed for pod %s, reason: %s\n", c.opt.HostName, err),
 				)
 			}
@@ -77,11 +85,30 @@ func (c *Controller) Run() (err error) {
 		return
 	}
 
-	cli := cli.New("/tmp", false, c.opt.HostName)
-	if _, err = cli.SetupEnv(restic.Spec.Backend, secret, c.opt.SmartPrefix); err != nil {
+	resticCLI := cli.New("/tmp", false, c.opt.HostName)
+	if _, err = resticCLI.SetupEnv(restic.Spec.Backend, secret, c.opt.SmartPrefix); err != nil {
 		return
 	}
 
-	err = cli.Check()
+	err = resticCLI.Check()
+	if cli.ErrorTypeOf(err) == cli.ErrorRepositoryLocked {
+		locks, uerr := util.RemoveStaleLocks(c.k8sClient, resticCLI, c.opt.Namespace, c.opt.Workload, restic.Spec.ExecutionPolicy)
+		if uerr != nil {
+			log.Errorf("Failed to remove stale locks, reason: %s\n", uerr)
+		} else if len(locks) > 0 {
+			ref, rerr := reference.GetReference(scheme.Scheme, restic)
+			if rerr == nil {
+				eventer.CreateEventWithLog(
+					c.k8sClient,
+					CheckEventComponent,
+					ref,
+					core.EventTypeNormal,
+					eventer.EventReasonRepositoryUnlocked,
+					util.StaleLocksMessage(locks),
+				)
+			}
+			err = resticCLI.Check()
+		}
+	}
 	return
 }
@@ -0,0 +1,58 @@
+package check
+
+import (
+	"github.com/appscode/go/log"
+	api "github.com/appscode/stash/apis/stash/v1alpha1"
+	"github.com/appscode/stash/pkg/cli"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+)
+
+// pushMetrics pushes the result of the check of the repository of hostname to pushgateway.
+func (c *Controller) pushMetrics(restic *api.Restic, err error) {
+	var (
+		restic_check_success = prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "restic",
+			Subsystem: "check",
+			Name:      "success",
+			Help:      "Indicates if check was successfully completed",
+		})
+		restic_check_fail = prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "restic",
+			Subsystem: "check",
+			Name:      "fail",
+			Help:      "Indicates if check failed",
+		})
+		restic_check_error = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "restic",
+			Subsystem: "check",
+			Name:      "error",
+			Help:      "Indicates the type of error that failed check",
+		}, []string{"type"})
+	)
+	for _, t := range cli.ErrorTypes {
+		restic_check_error.WithLabelValues(string(t)).Set(0)
+	}
+	if err != nil {
+		restic_check_success.Set(0)
+		restic_check_fail.Set(1)
+		restic_check_error.WithLabelValues(string(cli.ErrorTypeOf(err))).Set(1)
+	} else {
+		restic_check_success.Set(1)
+		restic_check_fail.Set(0)
+	}
+
+	// grouped by host of the repository, check jobs run in pods with generated names
+	labels := map[string]string{
+		"namespace":    restic.Namespace,
+		"stash_config": restic.Name,
+		"host":         c.opt.HostName,
+	}
+	if perr := push.Collectors(restic.Namespace+"-"+restic.Name, labels, c.opt.PushgatewayURL,
+		restic_check_success,
+		restic_check_fail,
+		restic_check_error,
+	); perr != nil {
+		log.Errorf("Failed to push metrics of check to %s, reason: %s\n", c.opt.PushgatewayURL, perr)
+	}
+}
@@ -0,0 +1,99 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	api "github.com/appscode/stash/apis/stash/v1alpha1"
+	"github.com/pkg/errors"
+	core "k8s.io/api/core/v1"
+)
+
+// BackendProvider configures restic for one type of backend.
+// Each provider handles exactly one field of api.Backend.
+type BackendProvider interface {
+	// Name of the api.Backend field handled by this provider.
+	Name() string
+	// Enabled returns true if the field handled by this provider is set in backend.
+	Enabled(backend *api.Backend) bool
+	// Location identifies the bucket or server that stores repositories of backend. Used to limit concurrent
+	// backups per backend. Empty if repositories of different workloads do not share storage, eg: hostPath.
+	Location(backend *api.Backend) string
+	// ValidateSecret checks that secret contains the keys required to access backend.
+	ValidateSecret(backend *api.Backend, secret *core.Secret) error
+	// Prefix returns the location of the repository inside backend for a workload with smart prefix autoPrefix.
+	Prefix(backend *api.Backend, autoPrefix string) (string, error)
+	// SetPrefix stores prefix returned by Prefix in backend. Used for Repository crd.
+	SetPrefix(backend *api.Backend, prefix string)
+	// TrimPrefix removes smart prefix autoPrefix from a backend previously updated by SetPrefix.
+	TrimPrefix(backend *api.Backend, autoPrefix string)
+	// Configure returns repository url, env vars and extended options used by restic to access backend.
+	// Files needed by restic, if any, are written inside scratchDir.
+	Configure(backend *api.Backend, secret *core.Secret, autoPrefix, scratchDir string) (*BackendConfig, error)
+	// VolumeAndMount returns volume and mount required by stash container to access backend, nil if not required.
+	VolumeAndMount(backend *api.Backend, volName string) (*core.Volume, *core.VolumeMount)
+}
+
+type BackendConfig struct {
+	Repository string
+	Env        map[string]string
+	// Extended options passed to restic using -o flag
+	Options []string
+	// Additional global flags passed to restic
+	Flags []string
+}
+
+var backendProviders []BackendProvider
+
+func RegisterBackendProvider(p BackendProvider) {
+	for _, v := range backendProviders {
+		if v.Name() == p.Name() {
+			panic(fmt.Sprintf("backend provider %s is already registered", p.Name()))
+		}
+	}
+	backendProviders = append(backendProviders, p)
+}
+
+// GetBackendProvider returns the provider for the only backend type set in backend.
+func GetBackendProvider(backend *api.Backend) (BackendProvider, error) {
+	var found []BackendProvider
+	for _, p := range backendProviders {
+		if p.Enabled(backend) {
+			found = append(found, p)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return nil, errors.New("missing backend")
+	case 1:
+		return found[0], nil
+	default:
+		names := make([]string, 0, len(found))
+		for _, p := range found {
+			names = append(names, p.Name())
+		}
+		return nil, fmt.Errorf("multiple backends specified: %s", strings.Join(names, ", "))
+	}
+}
+
+func requireSecretKeys(secret *core.Secret, keys ...string) error {
+	for _, key := range keys {
+		if _, ok := secret.Data[key]; !ok {
+			return fmt.Errorf("missing %s in secret %s/%s", key, secret.Namespace, secret.Name)
+		}
+	}
+	return nil
+}
+
+func envFromSecret(secret *core.Secret, keys ...string) map[string]string {
+	env := make(map[string]string, len(keys))
+	for _, key := range keys {
+		env[key] = string(secret.Data[key])
+	}
+	return env
+}
+
+func trimAutoPrefix(prefix, autoPrefix string) string {
+	prefix = strings.TrimSuffix(prefix, autoPrefix)
+	return strings.TrimSuffix(prefix, "/")
+}
@@ -1,7 +1,6 @@
 package cli
 
 import (
-	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -17,16 +16,21 @@ const (
 	RESTIC_REPOSITORY = "RESTIC_REPOSITORY"
 	RESTIC_PASSWORD   = "RESTIC_PASSWORD"
 	TMPDIR            = "TMPDIR"
+	// Frequency of status messages printed by restic in json mode
+	RESTIC_PROGRESS_FPS = "RESTIC_PROGRESS_FPS"
 
 	AWS_ACCESS_KEY_ID     = "AWS_ACCESS_KEY_ID"
 	AWS_SECRET_ACCESS_KEY = "AWS_SECRET_ACCESS_KEY"
+	AWS_SESSION_TOKEN     = "AWS_SESSION_TOKEN"
+	AWS_DEFAULT_REGION    = "AWS_DEFAULT_REGION"
 
 	GOOGLE_PROJECT_ID               = "GOOGLE_PROJECT_ID"
 	GOOGLE_SERVICE_ACCOUNT_JSON_KEY = "GOOGLE_SERVICE_ACCOUNT_JSON_KEY"
 	GOOGLE_APPLICATION_CREDENTIALS  = "GOOGLE_APPLICATION_CREDENTIALS"
 
-	AZURE_ACCOUNT_NAME = "AZURE_ACCOUNT_NAME"
-	AZURE_ACCOUNT_KEY  = "AZURE_ACCOUNT_KEY"
+	AZURE_ACCOUNT_NAME    = "AZURE_ACCOUNT_NAME"
+	AZURE_ACCOUNT_KEY     = "AZURE_ACCOUNT_KEY"
+	AZURE_ENDPOINT_SUFFIX = "AZURE_ENDPOINT_SUFFIX"
 
 	REST_SERVER_USERNAME = "REST_SERVER_USERNAME"
 	REST_SERVER_PASSWORD = "REST_SERVER_PASSWORD"
@@ -34,6 +38,13 @@ const (
 	B2_ACCOUNT_ID  = "B2_ACCOUNT_ID"
 	B2_ACCOUNT_KEY = "B2_ACCOUNT_KEY"
 
+	SSH_PRIVATE_KEY = "SSH_PRIVATE_KEY"
+
+	// Content of rclone.conf
+	RCLONE_CONFIG_DATA = "RCLONE_CONFIG_DATA"
+	// Path of rclone.conf, read by rclone
+	RCLONE_CONFIG = "RCLONE_CONFIG"
+
 	// For keystone v1 authentication
 	ST_AUTH = "ST_AUTH"
 	ST_USER = "ST_USER"
@@ -57,11 +68,20 @@ const (
 )
 
 func (w *ResticWrapper) SetupEnv(backend api.Backend, secret *core.Secret, autoPrefix string) (string, error) {
+	w.extendedOptions = nil
+
+	provider, err := GetBackendProvider(&backend)
+	if err != nil {
+		return "", err
+	}
 
 	if v, ok := secret.Data[RESTIC_PASSWORD]; !ok {
 		return "", errors.New("missing repository password")
 	} else {
-		w.sh.SetEnv(RESTIC_PASSWORD, string(v))
+		w.setEnv(RESTIC_PASSWORD, string(v))
+	}
+	if err = provider.ValidateSecret(&backend, secret); err != nil {
+		return "", err
 	}
 
 	if v, ok := secret.Data[CA_CERT_DATA]; ok {
@@ -80,100 +100,30 @@ func (w *ResticWrapper) SetupEnv(backend api.Backend, secret *core.Secret, autoP
 	if err := os.MkdirAll(tmpDir, 0755); err != nil {
 		return "", err
 	}
-	w.sh.SetEnv(TMPDIR, tmpDir)
-	prefix := ""
-	if backend.Local != nil {
-		r := filepath.Join(backend.Local.MountPath, autoPrefix)
-		prefix = filepath.Join(backend.Local.SubPath, autoPrefix)
-		if err := os.MkdirAll(r, 0755); err != nil {
-			return "", err
-		}
-		w.sh.SetEnv(RESTIC_REPOSITORY, r)
-	} else if backend.S3 != nil {
-		prefix = strings.TrimPrefix(filepath.Join(backend.S3.Bucket, backend.S3.Prefix, autoPrefix), "/")
-		r := fmt.Sprintf("s3:%s/%s", backend.S3.Endpoint, prefix)
-		w.sh.SetEnv(RESTIC_REPOSITORY, r)
-		w.sh.SetEnv(AWS_ACCESS_KEY_ID, string(secret.Data[AWS_ACCESS_KEY_ID]))
-		w.sh.SetEnv(AWS_SECRET_ACCESS_KEY, string(secret.Data[AWS_SECRET_ACCESS_KEY]))
-	} else if backend.GCS != nil {
-		prefix = strings.TrimPrefix(filepath.Join(backend.GCS.Prefix, autoPrefix), "/")
-		r := fmt.Sprintf("gs:%s:/%s", backend.GCS.Bucket, prefix)
-		w.sh.SetEnv(RESTIC_REPOSITORY, r)
-		w.sh.SetEnv(GOOGLE_PROJECT_ID, string(secret.Data[GOOGLE_PROJECT_ID]))
-		jsonKeyPath := filepath.Join(w.scratchDir, "gcs_sa.json")
-		err := ioutil.WriteFile(jsonKeyPath, secret.Data[GOOGLE_SERVICE_ACCOUNT_JSON_KEY], 0644)
-		if err != nil {
-			return "", err
-		}
-		w.sh.SetEnv(GOOGLE_APPLICATION_CREDENTIALS, jsonKeyPath)
-	} else if backend.Azure != nil {
-		prefix = strings.TrimPrefix(filepath.Join(backend.Azure.Prefix, autoPrefix), "/")
-		r := fmt.Sprintf("azure:%s:/%s", backend.Azure.Container, prefix)
-		w.sh.SetEnv(RESTIC_REPOSITORY, r)
-		w.sh.SetEnv(AZURE_ACCOUNT_NAME, string(secret.Data[AZURE_ACCOUNT_NAME]))
-		w.sh.SetEnv(AZURE_ACCOUNT_KEY, string(secret.Data[AZURE_ACCOUNT_KEY]))
-	} else if backend.Swift != nil {
-		prefix = strings.TrimPrefix(filepath.Join(backend.Swift.Prefix, autoPrefix), "/")
-		r := fmt.Sprintf("swift:%s:/%s", backend.Swift.Container, prefix)
-		w.sh.SetEnv(RESTIC_REPOSITORY, r)
-		// For keystone v1 authentication
-		w.sh.SetEnv(ST_AUTH, string(secret.Data[ST_AUTH]))
-		w.sh.SetEnv(ST_USER, string(secret.Data[ST_USER]))
-		w.sh.SetEnv(ST_KEY, string(secret.Data[ST_KEY]))
-		// For keystone v2 authentication (some variables are optional)
-		w.sh.SetEnv(OS_AUTH_URL, string(secret.Data[OS_AUTH_URL]))
-		w.sh.SetEnv(OS_REGION_NAME, string(secret.Data[OS_REGION_NAME]))
-		w.sh.SetEnv(OS_USERNAME, string(secret.Data[OS_USERNAME]))
-		w.sh.SetEnv(OS_PASSWORD, string(secret.Data[OS_PASSWORD]))
-		w.sh.SetEnv(OS_TENANT_ID, string(secret.Data[OS_TENANT_ID]))
-		w.sh.SetEnv(OS_TENANT_NAME, string(secret.Data[OS_TENANT_NAME]))
-		// For keystone v3 authentication (some variables are optional)
-		w.sh.SetEnv(OS_USER_DOMAIN_NAME, string(secret.Data[OS_USER_DOMAIN_NAME]))
-		w.sh.SetEnv(OS_PROJECT_NAME, string(secret.Data[AZURE_ACCOUNT_NAME]))
-		w.sh.SetEnv(AZURE_ACCOUNT_NAME, string(secret.Data[OS_PROJECT_NAME]))
-		w.sh.SetEnv(OS_PROJECT_DOMAIN_NAME, string(secret.Data[OS_PROJECT_DOMAIN_NAME]))
-		// For authentication based on tokens
-		w.sh.SetEnv(OS_STORAGE_URL, string(secret.Data[OS_STORAGE_URL]))
-		w.sh.SetEnv(OS_AUTH_TOKEN, string(secret.Data[OS_AUTH_TOKEN]))
-	} else if backend.B2 != nil {
-		prefix = strings.TrimPrefix(filepath.Join(backend.B2.Prefix, autoPrefix), "/")
-		r := fmt.Sprintf("b2:%s:/%s", backend.B2.Bucket, prefix)
-		w.sh.SetEnv(RESTIC_REPOSITORY, r)
-		w.sh.SetEnv(B2_ACCOUNT_ID, string(secret.Data[B2_ACCOUNT_ID]))
-		w.sh.SetEnv(B2_ACCOUNT_KEY, string(secret.Data[B2_ACCOUNT_KEY]))
-		/*
-			} else if backend.Rest != nil {
-				u, err := url.Parse(backend.Rest.URL)
-				if err != nil {
-					return err
-				}
-				if username, ok := secret.Data[REST_SERVER_USERNAME]; ok {
-					if password, ok := secret.Data[REST_SERVER_PASSWORD]; ok {
-						u.User = url.UserPassword(string(username), string(password))
-					} else {
-						u.User = url.User(string(username))
-					}
-				}
-				u.Path = filepath.Join(u.Path, autoPrefix) // TODO: check
-				r := fmt.Sprintf("rest:%s", u.String())
-				w.sh.SetEnv(RESTIC_REPOSITORY, r)
-			} else if backend.B2 != nil {
-				prefix := filepath.Join(backend.B2.Prefix, autoPrefix)
-				r := fmt.Sprintf("b2:%s:%s", backend.B2.Bucket, prefix)
-				w.sh.SetEnv(RESTIC_REPOSITORY, r)
-				w.sh.SetEnv(B2_ACCOUNT_ID, string(secret.Data[B2_ACCOUNT_ID]))
-				w.sh.SetEnv(B2_ACCOUNT_KEY, string(secret.Data[B2_ACCOUNT_KEY]))
-		*/
+	w.setEnv(TMPDIR, tmpDir)
+	w.setEnv(RESTIC_PROGRESS_FPS, "1")
+
+	prefix, err := provider.Prefix(&backend, autoPrefix)
+	if err != nil {
+		return "", err
 	}
+	cfg, err := provider.Configure(&backend, secret, autoPrefix, w.scratchDir)
+	if err != nil {
+		return "", err
+	}
+	w.setEnv(RESTIC_REPOSITORY, cfg.Repository)
+	for k, v := range cfg.Env {
+		w.setEnv(k, v)
+	}
+	for _, opt := range cfg.Options {
+		w.extendedOptions = append(w.extendedOptions, "-o", opt)
+	}
+	w.extendedOptions = append(w.extendedOptions, cfg.Flags...)
 
 	return prefix, nil
 }
 
 func (w *ResticWrapper) DumpEnv() error {
-	out, err := w.sh.Command("env").Output()
-	if err != nil {
-		return err
-	}
-	log.Debugf("ENV:\n%s", string(out))
+	log.Debugf("ENV:\n%s", strings.Join(w.environ(), "\n"))
 	return nil
 }
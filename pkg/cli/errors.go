@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ErrorType classifies failures reported by restic.
+type ErrorType string
+
+const (
+	ErrorUnknown            ErrorType = "Unknown"
+	ErrorRepositoryLocked   ErrorType = "RepositoryLocked"
+	ErrorWrongPassword      ErrorType = "WrongPassword"
+	ErrorRepositoryNotFound ErrorType = "RepositoryNotFound"
+	ErrorBackendUnreachable ErrorType = "BackendUnreachable"
+	ErrorPermissionDenied   ErrorType = "PermissionDenied"
+	ErrorOutOfSpace         ErrorType = "OutOfSpace"
+	ErrorTimeout            ErrorType = "Timeout"
+	ErrorCanceled           ErrorType = "Canceled"
+)
+
+// ErrorTypes lists every ErrorType, used to initialize per type metrics.
+var ErrorTypes = []ErrorType{
+	ErrorUnknown,
+	ErrorRepositoryLocked,
+	ErrorWrongPassword,
+	ErrorRepositoryNotFound,
+	ErrorBackendUnreachable,
+	ErrorPermissionDenied,
+	ErrorOutOfSpace,
+	ErrorTimeout,
+	ErrorCanceled,
+}
+
+// Patterns are matched against stderr of restic in order, first match wins.
+// Messages come from restic itself and from the storage libraries used by its backends.
+// restic appends "Is there a repository at the following location?" to every failure to open the config of
+// the repository, so patterns of authentication and network errors must be matched before RepositoryNotFound.
+var errorPatterns = []struct {
+	Type ErrorType
+	RE   *regexp.Regexp
+}{
+	{ErrorWrongPassword, regexp.MustCompile(`(?i)wrong password`)},
+	{ErrorRepositoryLocked, regexp.MustCompile(`(?i)repository is already locked|unable to create lock`)},
+	{ErrorOutOfSpace, regexp.MustCompile(`(?i)no space left on device|disk quota exceeded|quotaexceeded|insufficient storage`)},
+	{ErrorPermissionDenied, regexp.MustCompile(`(?i)permission denied|access ?denied|forbidden|authorizationfailure|invalidaccesskeyid|signaturedoesnotmatch|unauthorized`)},
+	{ErrorBackendUnreachable, regexp.MustCompile(`(?i)dial tcp|no such host|connection refused|connection reset|network is unreachable|i/o timeout|tls handshake timeout|no route to host|server misbehaving`)},
+	{ErrorRepositoryNotFound, regexp.MustCompile(`(?i)is there a repository at the following location|repository does not exist|nosuchbucket|specified bucket does not exist|containernotfound`)},
+}
+
+// ResticError is returned when a restic command fails.
+type ResticError struct {
+	Type     ErrorType
+	Command  string
+	ExitCode int
+	Stderr   string
+	cause    error
+}
+
+func (e *ResticError) Error() string {
+	switch e.Type {
+	case ErrorTimeout:
+		return fmt.Sprintf("restic %s timed out", e.Command)
+	case ErrorCanceled:
+		return fmt.Sprintf("restic %s was canceled", e.Command)
+	}
+	if msg := errorMessage(e.Stderr); msg != "" {
+		return msg
+	}
+	return fmt.Sprintf("restic %s failed: %v", e.Command, e.cause)
+}
+
+// ErrorTypeOf returns the ErrorType of err, ErrorUnknown if err was not returned by restic.
+func ErrorTypeOf(err error) ErrorType {
+	if e, ok := errors.Cause(err).(*ResticError); ok {
+		return e.Type
+	}
+	return ErrorUnknown
+}
+
+// IsTransient returns true if err may not happen again when the command is retried.
+func IsTransient(err error) bool {
+	switch ErrorTypeOf(err) {
+	case ErrorRepositoryLocked, ErrorBackendUnreachable, ErrorTimeout:
+		return true
+	}
+	return false
+}
+
+func classifyError(stderr string) ErrorType {
+	for _, p := range errorPatterns {
+		if p.RE.MatchString(stderr) {
+			return p.Type
+		}
+	}
+	return ErrorUnknown
+}
+
+// errorMessage returns the fatal error reported by restic. If not found, last non empty line of stderr is returned.
+func errorMessage(stderr string) string {
+	var last string
+	for _, line := range strings.Split(stderr, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "Fatal:") {
+			return strings.TrimSpace(strings.TrimPrefix(line, "Fatal:"))
+		}
+		last = line
+	}
+	return last
+}
@@ -0,0 +1,144 @@
+package cli
+
+import (
+	"errors"
+	"testing"
+
+	pkgerrors "github.com/pkg/errors"
+)
+
+func TestClassifyError(t *testing.T) {
+	cases := []struct {
+		name     string
+		stderr   string
+		expected ErrorType
+	}{
+		{
+			name:     "wrong password",
+			stderr:   "Fatal: wrong password or no key found\n",
+			expected: ErrorWrongPassword,
+		},
+		{
+			name: "locked",
+			stderr: "unable to create lock in backend: repository is already locked exclusively by PID 12 on stash-demo by root (UID 0, GID 0)\n" +
+				"lock was created at 2023-08-01 10:00:00 (1m0s ago)\n",
+			expected: ErrorRepositoryLocked,
+		},
+		{
+			name:     "out of space",
+			stderr:   "Fatal: unable to save snapshot: write /safe/data/data/ab/ab12: no space left on device\n",
+			expected: ErrorOutOfSpace,
+		},
+		{
+			name: "missing local repository",
+			stderr: "Fatal: repository does not exist: unable to open config file: stat /safe/data/config: no such file or directory\n" +
+				"Is there a repository at the following location?\n/safe/data\n",
+			expected: ErrorRepositoryNotFound,
+		},
+		{
+			name: "missing s3 repository",
+			stderr: "Fatal: unable to open config file: Stat: The specified key does not exist.\n" +
+				"Is there a repository at the following location?\ns3:s3.amazonaws.com/stash-qa/demo\n",
+			expected: ErrorRepositoryNotFound,
+		},
+		{
+			name:     "missing s3 bucket",
+			stderr:   "Fatal: create repository at s3:s3.amazonaws.com/stash-qa failed: client.BucketExists: NoSuchBucket\n",
+			expected: ErrorRepositoryNotFound,
+		},
+		{
+			name: "s3 access denied",
+			stderr: "Fatal: unable to open config file: Stat: Access Denied.\n" +
+				"Is there a repository at the following location?\ns3:s3.amazonaws.com/stash-qa/demo\n",
+			expected: ErrorPermissionDenied,
+		},
+		{
+			name: "s3 invalid key",
+			stderr: "Fatal: unable to open config file: Stat: The AWS Access Key Id you provided does not exist in our records. (InvalidAccessKeyId)\n" +
+				"Is there a repository at the following location?\ns3:s3.amazonaws.com/stash-qa/demo\n",
+			expected: ErrorPermissionDenied,
+		},
+		{
+			name: "local permission denied",
+			stderr: "Fatal: unable to open config file: open /safe/data/config: permission denied\n" +
+				"Is there a repository at the following location?\n/safe/data\n",
+			expected: ErrorPermissionDenied,
+		},
+		{
+			name: "unknown host",
+			stderr: "Fatal: unable to open config file: Stat: Get \"https://minio.storage.svc/stash-qa/?location=\": dial tcp: lookup minio.storage.svc: no such host\n" +
+				"Is there a repository at the following location?\ns3:https://minio.storage.svc/stash-qa/demo\n",
+			expected: ErrorBackendUnreachable,
+		},
+		{
+			name: "connection refused",
+			stderr: "Fatal: unable to open config file: Stat: Get \"http://10.0.0.12:9000/stash-qa/?location=\": dial tcp 10.0.0.12:9000: connect: connection refused\n" +
+				"Is there a repository at the following location?\ns3:http://10.0.0.12:9000/stash-qa/demo\n",
+			expected: ErrorBackendUnreachable,
+		},
+		{
+			name:     "unknown",
+			stderr:   "Fatal: invalid id \"xyz\": no matching ID found\n",
+			expected: ErrorUnknown,
+		},
+		{
+			name:     "empty",
+			stderr:   "",
+			expected: ErrorUnknown,
+		},
+	}
+	for _, c := range cases {
+		if got := classifyError(c.stderr); got != c.expected {
+			t.Errorf("%s: expected %s, got %s", c.name, c.expected, got)
+		}
+	}
+}
+
+func TestErrorTypeOf(t *testing.T) {
+	locked := &ResticError{Type: ErrorRepositoryLocked, Command: "backup"}
+	cases := []struct {
+		name      string
+		err       error
+		expected  ErrorType
+		transient bool
+	}{
+		{name: "nil", err: nil, expected: ErrorUnknown},
+		{name: "not restic", err: errors.New("failed"), expected: ErrorUnknown},
+		{name: "restic", err: locked, expected: ErrorRepositoryLocked, transient: true},
+		{name: "wrapped", err: pkgerrors.Wrap(locked, "backup failed"), expected: ErrorRepositoryLocked, transient: true},
+		{name: "wrong password", err: &ResticError{Type: ErrorWrongPassword}, expected: ErrorWrongPassword},
+	}
+	for _, c := range cases {
+		if got := ErrorTypeOf(c.err); got != c.expected {
+			t.Errorf("%s: expected %s, got %s", c.name, c.expected, got)
+		}
+		if got := IsTransient(c.err); got != c.transient {
+			t.Errorf("%s: expected transient %v, got %v", c.name, c.transient, got)
+		}
+	}
+}
+
+func TestErrorMessage(t *testing.T) {
+	cases := []struct {
+		stderr   string
+		expected string
+	}{
+		{
+			stderr:   "Fatal: wrong password or no key found\n",
+			expected: "wrong password or no key found",
+		},
+		{
+			stderr:   "scan [/source/data]\nunable to create lock in backend: repository is already locked\n\n",
+			expected: "unable to create lock in backend: repository is already locked",
+		},
+		{
+			stderr:   "",
+			expected: "",
+		},
+	}
+	for _, c := range cases {
+		if got := errorMessage(c.stderr); got != c.expected {
+			t.Errorf("stderr %q: expected %q, got %q", c.stderr, c.expected, got)
+		}
+	}
+}
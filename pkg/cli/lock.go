@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// StaleLockAge is the duration after which restic considers a lock that was not refreshed stale.
+const StaleLockAge = 30 * time.Minute
+
+// Lock is a lock of a restic repository. Running restic refreshes its locks every 5 minutes, so Time is the last refresh.
+type Lock struct {
+	ID        string    `json:"id"`
+	Time      time.Time `json:"time"`
+	Exclusive bool      `json:"exclusive"`
+	Hostname  string    `json:"hostname"`
+	Username  string    `json:"username"`
+	PID       int       `json:"pid"`
+}
+
+// Age returns the duration since the lock was last refreshed.
+func (l Lock) Age() time.Duration {
+	return time.Since(l.Time)
+}
+
+func (l Lock) String() string {
+	id := l.ID
+	if len(id) > 8 {
+		id = id[:8]
+	}
+	return fmt.Sprintf("%s (host %s, pid %d, age %s)", id, l.Hostname, l.PID, l.Age().Round(time.Second))
+}
+
+// ListLocks returns the locks of the repository.
+func (w *ResticWrapper) ListLocks() ([]Lock, error) {
+	args := w.appendCacheDirFlag([]interface{}{"list", "locks", "--quiet", "--no-lock"})
+	args = w.appendCaCertFlag(args)
+	args = w.appendExtendedOptions(args)
+
+	out, err := w.run(Exe, args)
+	if err != nil {
+		return nil, err
+	}
+
+	locks := make([]Lock, 0)
+	for _, id := range bytes.Fields(out) {
+		args := w.appendCacheDirFlag([]interface{}{"cat", "lock", string(id), "--quiet", "--no-lock"})
+		args = w.appendCaCertFlag(args)
+		args = w.appendExtendedOptions(args)
+
+		out, err := w.run(Exe, args)
+		if err != nil {
+			// lock was removed by its owner meanwhile
+			continue
+		}
+		lock := Lock{ID: string(id)}
+		if err = json.Unmarshal(bytes.TrimSpace(out), &lock); err != nil {
+			return nil, err
+		}
+		locks = append(locks, lock)
+	}
+	return locks, nil
+}
+
+// RemoveStaleLocks removes the locks that restic considers stale, ie: locks not refreshed within StaleLockAge and
+// locks of this host whose process no longer runs. restic checks each lock when removing it, so a lock of a running
+// restic process is never removed, even if it was taken after the locks were listed.
+func (w *ResticWrapper) RemoveStaleLocks() error {
+	args := w.appendCacheDirFlag([]interface{}{"unlock"})
+	args = w.appendCaCertFlag(args)
+	args = w.appendExtendedOptions(args)
+
+	_, err := w.run(Exe, args)
+	return err
+}
+
+// RemoveAllLocks removes all locks of the repository, including locks of running restic processes. Callers have to make
+// sure that every lock is stale, see util.RemoveStaleLocks.
+func (w *ResticWrapper) RemoveAllLocks() error {
+	args := w.appendCacheDirFlag([]interface{}{"unlock", "--remove-all"})
+	args = w.appendCaCertFlag(args)
+	args = w.appendExtendedOptions(args)
+
+	_, err := w.run(Exe, args)
+	return err
+}
@@ -0,0 +1,143 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// Progress is the status message printed periodically by restic backup and restore in json mode.
+type Progress struct {
+	PercentDone      float64 `json:"percent_done"`
+	SecondsElapsed   int64   `json:"seconds_elapsed"`
+	SecondsRemaining int64   `json:"seconds_remaining"`
+	TotalFiles       int64   `json:"total_files"`
+	FilesDone        int64   `json:"files_done"`
+	TotalBytes       int64   `json:"total_bytes"`
+	BytesDone        int64   `json:"bytes_done"`
+}
+
+// restoreStatus is the status message printed by restic restore, normalized to Progress.
+type restoreStatus struct {
+	Progress
+	FilesRestored int64 `json:"files_restored"`
+	BytesRestored int64 `json:"bytes_restored"`
+}
+
+// ProgressFunc is called with each status message printed by restic.
+type ProgressFunc func(Progress)
+
+// WithProgress returns a copy of w that reports progress of Backup and Restore to fn.
+func (w *ResticWrapper) WithProgress(fn ProgressFunc) *ResticWrapper {
+	c := *w
+	c.progress = fn
+	return &c
+}
+
+// ThrottleProgress returns a ProgressFunc that calls fn at most once per interval.
+// The final status message (100% done) is always reported.
+// Progress of a running command is reported from its own goroutine, so fn may block, eg: to update the Repository.
+func ThrottleProgress(interval time.Duration, fn ProgressFunc) ProgressFunc {
+	var (
+		mu   sync.Mutex
+		last time.Time
+	)
+	return func(p Progress) {
+		mu.Lock()
+		now := time.Now()
+		if p.PercentDone < 1 && now.Sub(last) < interval {
+			mu.Unlock()
+			return
+		}
+		last = now
+		mu.Unlock()
+		fn(p)
+	}
+}
+
+// publishProgress returns a ProgressFunc that passes status messages to fn in a separate goroutine, so that
+// output of restic is never blocked by fn. A status message that was not yet passed to fn is dropped when the next
+// one arrives. stop waits until fn returned for the last status message.
+func publishProgress(fn ProgressFunc) (publish ProgressFunc, stop func()) {
+	ch := make(chan Progress, 1)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for p := range ch {
+			fn(p)
+		}
+	}()
+	publish = func(p Progress) {
+		for {
+			select {
+			case ch <- p:
+				return
+			default:
+			}
+			// drop the stale status message
+			select {
+			case <-ch:
+			default:
+			}
+		}
+	}
+	stop = func() {
+		close(ch)
+		<-done
+	}
+	return publish, stop
+}
+
+var statusPrefix = []byte(`{"message_type":"status"`)
+
+// statusWriter passes status messages printed by restic to fn and buffers the remaining output.
+// Status messages are printed many times a second, so they are never buffered.
+type statusWriter struct {
+	fn      ProgressFunc
+	out     bytes.Buffer
+	partial []byte
+}
+
+func (s *statusWriter) Write(p []byte) (int, error) {
+	s.partial = append(s.partial, p...)
+	for {
+		i := bytes.IndexByte(s.partial, '\n')
+		if i < 0 {
+			break
+		}
+		s.writeLine(s.partial[:i+1])
+		s.partial = s.partial[i+1:]
+	}
+	return len(p), nil
+}
+
+func (s *statusWriter) writeLine(line []byte) {
+	if !bytes.HasPrefix(line, statusPrefix) {
+		s.out.Write(line)
+		return
+	}
+	if s.fn == nil {
+		return
+	}
+	var status restoreStatus
+	if err := json.Unmarshal(line, &status); err != nil {
+		return
+	}
+	p := status.Progress
+	if status.FilesRestored > 0 {
+		p.FilesDone = status.FilesRestored
+	}
+	if status.BytesRestored > 0 {
+		p.BytesDone = status.BytesRestored
+	}
+	if p.SecondsRemaining == 0 && p.PercentDone > 0 && p.PercentDone < 1 {
+		p.SecondsRemaining = int64(float64(p.SecondsElapsed) * (1 - p.PercentDone) / p.PercentDone)
+	}
+	s.fn(p)
+}
+
+// Bytes returns output other than status messages.
+func (s *statusWriter) Bytes() []byte {
+	return append(s.out.Bytes(), s.partial...)
+}
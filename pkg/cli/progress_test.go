@@ -0,0 +1,136 @@
+package cli
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestStatusWriter(t *testing.T) {
+	cases := []struct {
+		name     string
+		writes   []string
+		progress []Progress
+		out      string
+	}{
+		{
+			name: "backup",
+			writes: []string{
+				`{"message_type":"status","seconds_elapsed":10,"percent_done":0.25,"total_files":4,"files_done":1,"total_bytes":400,"bytes_done":100}` + "\n",
+				`{"message_type":"status","seconds_elapsed":20,"seconds_remaining":5,"percent_done":0.8,"total_files":4,"files_done":3,"total_bytes":400,"bytes_done":320}` + "\n",
+				`{"message_type":"summary","snapshot_id":"4f1c3b0e"}` + "\n",
+			},
+			progress: []Progress{
+				{PercentDone: 0.25, SecondsElapsed: 10, SecondsRemaining: 30, TotalFiles: 4, FilesDone: 1, TotalBytes: 400, BytesDone: 100},
+				{PercentDone: 0.8, SecondsElapsed: 20, SecondsRemaining: 5, TotalFiles: 4, FilesDone: 3, TotalBytes: 400, BytesDone: 320},
+			},
+			out: `{"message_type":"summary","snapshot_id":"4f1c3b0e"}` + "\n",
+		},
+		{
+			name: "restore",
+			writes: []string{
+				`{"message_type":"status","seconds_elapsed":1,"percent_done":1,"total_files":2,"files_restored":2,"total_bytes":50,"bytes_restored":50}` + "\n",
+			},
+			progress: []Progress{
+				{PercentDone: 1, SecondsElapsed: 1, TotalFiles: 2, FilesDone: 2, TotalBytes: 50, BytesDone: 50},
+			},
+		},
+		{
+			name: "lines split across writes",
+			writes: []string{
+				`{"message_type":"status","percent_`,
+				`done":1,"total_bytes":8,"bytes_done":8}` + "\nfirst line\nsecond",
+				" line\n",
+			},
+			progress: []Progress{
+				{PercentDone: 1, TotalBytes: 8, BytesDone: 8},
+			},
+			out: "first line\nsecond line\n",
+		},
+		{
+			name:   "incomplete last line",
+			writes: []string{"[0:01] 100.00%  done\nno newline"},
+			out:    "[0:01] 100.00%  done\nno newline",
+		},
+		{
+			name: "invalid status",
+			writes: []string{
+				`{"message_type":"status","percent_done":"x"}` + "\n",
+			},
+		},
+	}
+	for _, c := range cases {
+		var progress []Progress
+		s := &statusWriter{fn: func(p Progress) {
+			progress = append(progress, p)
+		}}
+		for _, w := range c.writes {
+			if n, err := s.Write([]byte(w)); err != nil || n != len(w) {
+				t.Fatalf("%s: write returned %d, %v", c.name, n, err)
+			}
+		}
+		if !reflect.DeepEqual(progress, c.progress) {
+			t.Errorf("%s: expected progress %+v, got %+v", c.name, c.progress, progress)
+		}
+		if out := string(s.Bytes()); out != c.out {
+			t.Errorf("%s: expected output %q, got %q", c.name, c.out, out)
+		}
+	}
+}
+
+func TestStatusWriterWithoutProgress(t *testing.T) {
+	s := &statusWriter{}
+	s.Write([]byte(`{"message_type":"status","percent_done":0.5}` + "\nsnapshot saved\n"))
+	if out := string(s.Bytes()); out != "snapshot saved\n" {
+		t.Errorf("expected status message to be dropped, got %q", out)
+	}
+}
+
+func TestPublishProgressDropsStaleStatus(t *testing.T) {
+	started, block := make(chan struct{}), make(chan struct{})
+	var published []Progress
+	publish, stop := publishProgress(func(p Progress) {
+		if len(published) == 0 {
+			// slow update, eg: of the Repository
+			close(started)
+			<-block
+		}
+		published = append(published, p)
+	})
+
+	publish(Progress{PercentDone: 0.1})
+	<-started
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 2; i <= 10; i++ {
+			publish(Progress{PercentDone: float64(i) / 10})
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("publish blocked by slow update")
+	}
+	close(block)
+	stop()
+
+	expected := []Progress{{PercentDone: 0.1}, {PercentDone: 1}}
+	if !reflect.DeepEqual(published, expected) {
+		t.Errorf("expected %+v, got %+v", expected, published)
+	}
+}
+
+func TestThrottleProgress(t *testing.T) {
+	var reported []Progress
+	fn := ThrottleProgress(time.Hour, func(p Progress) {
+		reported = append(reported, p)
+	})
+	for _, p := range []float64{0.1, 0.2, 0.5, 1} {
+		fn(Progress{PercentDone: p})
+	}
+	expected := []Progress{{PercentDone: 0.1}, {PercentDone: 1}}
+	if !reflect.DeepEqual(reported, expected) {
+		t.Errorf("expected %+v, got %+v", expected, reported)
+	}
+}
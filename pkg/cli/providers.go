@@ -0,0 +1,535 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	api "github.com/appscode/stash/apis/stash/v1alpha1"
+	"github.com/pkg/errors"
+	core "k8s.io/api/core/v1"
+)
+
+func init() {
+	RegisterBackendProvider(localBackend{})
+	RegisterBackendProvider(s3Backend{})
+	RegisterBackendProvider(gcsBackend{})
+	RegisterBackendProvider(azureBackend{})
+	RegisterBackendProvider(swiftBackend{})
+	RegisterBackendProvider(b2Backend{})
+	RegisterBackendProvider(restBackend{})
+	RegisterBackendProvider(sftpBackend{})
+	RegisterBackendProvider(rcloneBackend{})
+}
+
+// noMount is embedded by providers of remote backends.
+type noMount struct{}
+
+func (noMount) VolumeAndMount(backend *api.Backend, volName string) (*core.Volume, *core.VolumeMount) {
+	return nil, nil
+}
+
+type localBackend struct{}
+
+func (localBackend) Name() string { return "local" }
+
+func (localBackend) Enabled(backend *api.Backend) bool { return backend.Local != nil }
+
+func (localBackend) Location(backend *api.Backend) string {
+	switch {
+	case backend.Local.NFS != nil:
+		return "nfs:" + backend.Local.NFS.Server + ":" + backend.Local.NFS.Path
+	case backend.Local.PersistentVolumeClaim != nil:
+		return "pvc:" + backend.Local.PersistentVolumeClaim.ClaimName
+	}
+	return ""
+}
+
+func (localBackend) ValidateSecret(backend *api.Backend, secret *core.Secret) error { return nil }
+
+func (localBackend) Prefix(backend *api.Backend, autoPrefix string) (string, error) {
+	return filepath.Join(backend.Local.SubPath, autoPrefix), nil
+}
+
+func (localBackend) SetPrefix(backend *api.Backend, prefix string) {
+	backend.Local.SubPath = prefix
+}
+
+func (localBackend) TrimPrefix(backend *api.Backend, autoPrefix string) {
+	backend.Local.SubPath = trimAutoPrefix(backend.Local.SubPath, autoPrefix)
+}
+
+func (localBackend) Configure(backend *api.Backend, secret *core.Secret, autoPrefix, scratchDir string) (*BackendConfig, error) {
+	r := filepath.Join(backend.Local.MountPath, autoPrefix)
+	if err := os.MkdirAll(r, 0755); err != nil {
+		return nil, err
+	}
+	return &BackendConfig{Repository: r}, nil
+}
+
+func (localBackend) VolumeAndMount(backend *api.Backend, volName string) (*core.Volume, *core.VolumeMount) {
+	vol, mnt := backend.Local.ToVolumeAndMount(volName)
+	return &vol, &mnt
+}
+
+type s3Backend struct{ noMount }
+
+func (s3Backend) Name() string { return "s3" }
+
+func (s3Backend) Enabled(backend *api.Backend) bool { return backend.S3 != nil }
+
+func (s3Backend) Location(backend *api.Backend) string {
+	return "s3:" + backend.S3.Endpoint + "/" + backend.S3.Bucket
+}
+
+func (s3Backend) ValidateSecret(backend *api.Backend, secret *core.Secret) error {
+	switch backend.S3.BucketLookup {
+	case "", api.S3BucketLookupAuto, api.S3BucketLookupDNS, api.S3BucketLookupPath:
+	default:
+		return fmt.Errorf("invalid s3 bucket lookup %s", backend.S3.BucketLookup)
+	}
+	return requireSecretKeys(secret, AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY)
+}
+
+func (s3Backend) Prefix(backend *api.Backend, autoPrefix string) (string, error) {
+	return strings.TrimPrefix(filepath.Join(backend.S3.Prefix, autoPrefix), "/"), nil
+}
+
+// SetPrefix stores the bucket in front of prefix, as Repositories created by older versions do.
+func (s3Backend) SetPrefix(backend *api.Backend, prefix string) {
+	backend.S3.Prefix = strings.TrimSuffix(filepath.Join(backend.S3.Bucket, prefix), "/")
+}
+
+// TrimPrefix removes the bucket stored by SetPrefix in front of the prefix.
+func (s3Backend) TrimPrefix(backend *api.Backend, autoPrefix string) {
+	prefix := trimAutoPrefix(backend.S3.Prefix, autoPrefix)
+	if prefix == backend.S3.Bucket {
+		prefix = ""
+	}
+	backend.S3.Prefix = strings.TrimPrefix(prefix, backend.S3.Bucket+"/")
+}
+
+func (p s3Backend) Configure(backend *api.Backend, secret *core.Secret, autoPrefix, scratchDir string) (*BackendConfig, error) {
+	prefix, err := p.Prefix(backend, autoPrefix)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &BackendConfig{
+		Repository: fmt.Sprintf("s3:%s/%s", backend.S3.Endpoint, filepath.Join(backend.S3.Bucket, prefix)),
+		Env:        envFromSecret(secret, AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, AWS_SESSION_TOKEN),
+	}
+	if backend.S3.Region != "" {
+		cfg.Env[AWS_DEFAULT_REGION] = backend.S3.Region
+		cfg.Options = append(cfg.Options, "s3.region="+backend.S3.Region)
+	}
+	if backend.S3.BucketLookup != "" {
+		cfg.Options = append(cfg.Options, "s3.bucket-lookup="+string(backend.S3.BucketLookup))
+	}
+	if backend.S3.StorageClass != "" {
+		cfg.Options = append(cfg.Options, "s3.storage-class="+backend.S3.StorageClass)
+	}
+	if backend.S3.InsecureSkipTLSVerify {
+		cfg.Flags = append(cfg.Flags, "--insecure-tls")
+	}
+	return cfg, nil
+}
+
+type gcsBackend struct{ noMount }
+
+func (gcsBackend) Name() string { return "gcs" }
+
+func (gcsBackend) Enabled(backend *api.Backend) bool { return backend.GCS != nil }
+
+func (gcsBackend) Location(backend *api.Backend) string { return "gcs:" + backend.GCS.Bucket }
+
+func (gcsBackend) ValidateSecret(backend *api.Backend, secret *core.Secret) error {
+	return requireSecretKeys(secret, GOOGLE_PROJECT_ID, GOOGLE_SERVICE_ACCOUNT_JSON_KEY)
+}
+
+func (gcsBackend) Prefix(backend *api.Backend, autoPrefix string) (string, error) {
+	return strings.TrimPrefix(filepath.Join(backend.GCS.Prefix, autoPrefix), "/"), nil
+}
+
+func (gcsBackend) SetPrefix(backend *api.Backend, prefix string) {
+	backend.GCS.Prefix = prefix
+}
+
+func (gcsBackend) TrimPrefix(backend *api.Backend, autoPrefix string) {
+	backend.GCS.Prefix = trimAutoPrefix(backend.GCS.Prefix, autoPrefix)
+}
+
+func (p gcsBackend) Configure(backend *api.Backend, secret *core.Secret, autoPrefix, scratchDir string) (*BackendConfig, error) {
+	prefix, err := p.Prefix(backend, autoPrefix)
+	if err != nil {
+		return nil, err
+	}
+	jsonKeyPath := filepath.Join(scratchDir, "gcs_sa.json")
+	if err := ioutil.WriteFile(jsonKeyPath, secret.Data[GOOGLE_SERVICE_ACCOUNT_JSON_KEY], 0644); err != nil {
+		return nil, err
+	}
+	env := envFromSecret(secret, GOOGLE_PROJECT_ID)
+	env[GOOGLE_APPLICATION_CREDENTIALS] = jsonKeyPath
+	return &BackendConfig{
+		Repository: fmt.Sprintf("gs:%s:/%s", backend.GCS.Bucket, prefix),
+		Env:        env,
+	}, nil
+}
+
+type azureBackend struct{ noMount }
+
+func (azureBackend) Name() string { return "azure" }
+
+func (azureBackend) Enabled(backend *api.Backend) bool { return backend.Azure != nil }
+
+func (azureBackend) Location(backend *api.Backend) string { return "azure:" + backend.Azure.Container }
+
+func (azureBackend) ValidateSecret(backend *api.Backend, secret *core.Secret) error {
+	return requireSecretKeys(secret, AZURE_ACCOUNT_NAME, AZURE_ACCOUNT_KEY)
+}
+
+func (azureBackend) Prefix(backend *api.Backend, autoPrefix string) (string, error) {
+	return strings.TrimPrefix(filepath.Join(backend.Azure.Prefix, autoPrefix), "/"), nil
+}
+
+func (azureBackend) SetPrefix(backend *api.Backend, prefix string) {
+	backend.Azure.Prefix = prefix
+}
+
+func (azureBackend) TrimPrefix(backend *api.Backend, autoPrefix string) {
+	backend.Azure.Prefix = trimAutoPrefix(backend.Azure.Prefix, autoPrefix)
+}
+
+func (p azureBackend) Configure(backend *api.Backend, secret *core.Secret, autoPrefix, scratchDir string) (*BackendConfig, error) {
+	prefix, err := p.Prefix(backend, autoPrefix)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &BackendConfig{
+		Repository: fmt.Sprintf("azure:%s:/%s", backend.Azure.Container, prefix),
+		Env:        envFromSecret(secret, AZURE_ACCOUNT_NAME, AZURE_ACCOUNT_KEY),
+	}
+	suffix := backend.Azure.EndpointSuffix
+	if backend.Azure.Endpoint != "" {
+		if suffix != "" {
+			return nil, errors.New("only one of azure endpoint and endpointSuffix can be set")
+		}
+		if suffix, err = azureEndpointSuffix(backend.Azure.Endpoint, cfg.Env[AZURE_ACCOUNT_NAME]); err != nil {
+			return nil, err
+		}
+	}
+	if suffix != "" {
+		cfg.Env[AZURE_ENDPOINT_SUFFIX] = suffix
+	}
+	return cfg, nil
+}
+
+// azureEndpointSuffix returns the endpoint suffix of endpoint of the blob service of account. restic builds the
+// endpoint as https://<account>.blob.<suffix>, so other endpoints, eg: of Azurite with the account in the path,
+// are rejected instead of being ignored.
+func azureEndpointSuffix(endpoint, account string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", errors.Wrapf(err, "azure endpoint %s is invalid", endpoint)
+	}
+	host := account + ".blob."
+	if u.Scheme != "https" || !strings.HasPrefix(u.Host, host) || u.Host == host || strings.Trim(u.Path, "/") != "" {
+		return "", errors.Errorf("azure endpoint %s is not supported, restic only accesses https://%s<endpointSuffix>", endpoint, host)
+	}
+	return strings.TrimPrefix(u.Host, host), nil
+}
+
+type swiftBackend struct{ noMount }
+
+func (swiftBackend) Name() string { return "swift" }
+
+func (swiftBackend) Enabled(backend *api.Backend) bool { return backend.Swift != nil }
+
+func (swiftBackend) Location(backend *api.Backend) string { return "swift:" + backend.Swift.Container }
+
+// Swift supports several authentication methods with different sets of keys, so none is required here.
+func (swiftBackend) ValidateSecret(backend *api.Backend, secret *core.Secret) error { return nil }
+
+func (swiftBackend) Prefix(backend *api.Backend, autoPrefix string) (string, error) {
+	return strings.TrimPrefix(filepath.Join(backend.Swift.Prefix, autoPrefix), "/"), nil
+}
+
+func (swiftBackend) SetPrefix(backend *api.Backend, prefix string) {
+	backend.Swift.Prefix = prefix
+}
+
+func (swiftBackend) TrimPrefix(backend *api.Backend, autoPrefix string) {
+	backend.Swift.Prefix = trimAutoPrefix(backend.Swift.Prefix, autoPrefix)
+}
+
+func (p swiftBackend) Configure(backend *api.Backend, secret *core.Secret, autoPrefix, scratchDir string) (*BackendConfig, error) {
+	prefix, err := p.Prefix(backend, autoPrefix)
+	if err != nil {
+		return nil, err
+	}
+	return &BackendConfig{
+		Repository: fmt.Sprintf("swift:%s:/%s", backend.Swift.Container, prefix),
+		Env: envFromSecret(secret,
+			// For keystone v1 authentication
+			ST_AUTH,
+			ST_USER,
+			ST_KEY,
+			// For keystone v2 authentication (some variables are optional)
+			OS_AUTH_URL,
+			OS_REGION_NAME,
+			OS_USERNAME,
+			OS_PASSWORD,
+			OS_TENANT_ID,
+			OS_TENANT_NAME,
+			// For keystone v3 authentication (some variables are optional)
+			OS_USER_DOMAIN_NAME,
+			OS_PROJECT_NAME,
+			OS_PROJECT_DOMAIN_NAME,
+			// For authentication based on tokens
+			OS_STORAGE_URL,
+			OS_AUTH_TOKEN,
+		),
+	}, nil
+}
+
+type b2Backend struct{ noMount }
+
+func (b2Backend) Name() string { return "b2" }
+
+func (b2Backend) Enabled(backend *api.Backend) bool { return backend.B2 != nil }
+
+func (b2Backend) Location(backend *api.Backend) string { return "b2:" + backend.B2.Bucket }
+
+func (b2Backend) ValidateSecret(backend *api.Backend, secret *core.Secret) error {
+	return requireSecretKeys(secret, B2_ACCOUNT_ID, B2_ACCOUNT_KEY)
+}
+
+func (b2Backend) Prefix(backend *api.Backend, autoPrefix string) (string, error) {
+	return strings.TrimPrefix(filepath.Join(backend.B2.Prefix, autoPrefix), "/"), nil
+}
+
+func (b2Backend) SetPrefix(backend *api.Backend, prefix string) {
+	backend.B2.Prefix = prefix
+}
+
+func (b2Backend) TrimPrefix(backend *api.Backend, autoPrefix string) {
+	backend.B2.Prefix = trimAutoPrefix(backend.B2.Prefix, autoPrefix)
+}
+
+func (p b2Backend) Configure(backend *api.Backend, secret *core.Secret, autoPrefix, scratchDir string) (*BackendConfig, error) {
+	prefix, err := p.Prefix(backend, autoPrefix)
+	if err != nil {
+		return nil, err
+	}
+	return &BackendConfig{
+		Repository: fmt.Sprintf("b2:%s:/%s", backend.B2.Bucket, prefix),
+		Env:        envFromSecret(secret, B2_ACCOUNT_ID, B2_ACCOUNT_KEY),
+	}, nil
+}
+
+type restBackend struct{ noMount }
+
+func (restBackend) Name() string { return "rest" }
+
+func (restBackend) Enabled(backend *api.Backend) bool { return backend.Rest != nil }
+
+func (restBackend) Location(backend *api.Backend) string {
+	if u, err := url.Parse(backend.Rest.URL); err == nil && u.Host != "" {
+		return "rest:" + u.Host
+	}
+	return "rest:" + backend.Rest.URL
+}
+
+func (restBackend) ValidateSecret(backend *api.Backend, secret *core.Secret) error {
+	if _, ok := secret.Data[REST_SERVER_PASSWORD]; ok {
+		return requireSecretKeys(secret, REST_SERVER_USERNAME)
+	}
+	return nil
+}
+
+// Prefix returns the url of the repository. Credentials are never included, since it is stored in the Repository.
+func (restBackend) Prefix(backend *api.Backend, autoPrefix string) (string, error) {
+	u, err := url.Parse(backend.Rest.URL)
+	if err != nil {
+		return "", err
+	}
+	u.User = nil
+	u.Path = strings.TrimSuffix(filepath.Join(u.Path, autoPrefix), "/")
+	return u.String(), nil
+}
+
+func (restBackend) SetPrefix(backend *api.Backend, prefix string) {
+	backend.Rest.URL = prefix
+}
+
+func (restBackend) TrimPrefix(backend *api.Backend, autoPrefix string) {
+	if u, err := url.Parse(backend.Rest.URL); err == nil {
+		u.Path = trimAutoPrefix(u.Path, autoPrefix)
+		backend.Rest.URL = u.String()
+	}
+}
+
+func (p restBackend) Configure(backend *api.Backend, secret *core.Secret, autoPrefix, scratchDir string) (*BackendConfig, error) {
+	prefix, err := p.Prefix(backend, autoPrefix)
+	if err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(prefix)
+	if err != nil {
+		return nil, err
+	}
+	if username, ok := secret.Data[REST_SERVER_USERNAME]; ok {
+		if password, ok := secret.Data[REST_SERVER_PASSWORD]; ok {
+			u.User = url.UserPassword(string(username), string(password))
+		} else {
+			u.User = url.User(string(username))
+		}
+	}
+	return &BackendConfig{Repository: fmt.Sprintf("rest:%s", u.String())}, nil
+}
+
+type sftpBackend struct{ noMount }
+
+func (sftpBackend) Name() string { return "sftp" }
+
+func (sftpBackend) Enabled(backend *api.Backend) bool { return backend.SFTP != nil }
+
+func (sftpBackend) Location(backend *api.Backend) string { return "sftp:" + backend.SFTP.Host }
+
+func (sftpBackend) ValidateSecret(backend *api.Backend, secret *core.Secret) error {
+	if backend.SFTP.Host == "" {
+		return errors.New("missing sftp host")
+	}
+	if backend.SFTP.KnownHosts == "" && !backend.SFTP.InsecureSkipHostKeyVerification {
+		return errors.New("missing sftp knownHosts, set sftp.insecureSkipHostKeyVerification to skip host key verification")
+	}
+	return requireSecretKeys(secret, SSH_PRIVATE_KEY)
+}
+
+func (sftpBackend) Prefix(backend *api.Backend, autoPrefix string) (string, error) {
+	return strings.TrimSuffix(filepath.Join(backend.SFTP.Path, autoPrefix), "/"), nil
+}
+
+func (sftpBackend) SetPrefix(backend *api.Backend, prefix string) {
+	backend.SFTP.Path = prefix
+}
+
+func (sftpBackend) TrimPrefix(backend *api.Backend, autoPrefix string) {
+	backend.SFTP.Path = trimAutoPrefix(backend.SFTP.Path, autoPrefix)
+}
+
+func (p sftpBackend) Configure(backend *api.Backend, secret *core.Secret, autoPrefix, scratchDir string) (*BackendConfig, error) {
+	prefix, err := p.Prefix(backend, autoPrefix)
+	if err != nil {
+		return nil, err
+	}
+	sshConfig, err := writeSSHConfig(backend.SFTP, secret, scratchDir)
+	if err != nil {
+		return nil, err
+	}
+	host := backend.SFTP.Host
+	if backend.SFTP.User != "" {
+		host = backend.SFTP.User + "@" + host
+	}
+	return &BackendConfig{
+		Repository: fmt.Sprintf("sftp:%s:%s", host, prefix),
+		// identity file and known_hosts can only be passed to ssh through a config file
+		Options: []string{fmt.Sprintf("sftp.command=ssh -F %s %s -s sftp", sshConfig, backend.SFTP.Host)},
+	}, nil
+}
+
+type rcloneBackend struct{ noMount }
+
+func (rcloneBackend) Name() string { return "rclone" }
+
+func (rcloneBackend) Enabled(backend *api.Backend) bool { return backend.Rclone != nil }
+
+func (rcloneBackend) Location(backend *api.Backend) string { return "rclone:" + backend.Rclone.Remote }
+
+func (rcloneBackend) ValidateSecret(backend *api.Backend, secret *core.Secret) error {
+	if backend.Rclone.Remote == "" {
+		return errors.New("missing rclone remote")
+	}
+	return requireSecretKeys(secret, RCLONE_CONFIG_DATA)
+}
+
+func (rcloneBackend) Prefix(backend *api.Backend, autoPrefix string) (string, error) {
+	return strings.TrimSuffix(filepath.Join(backend.Rclone.Path, autoPrefix), "/"), nil
+}
+
+func (rcloneBackend) SetPrefix(backend *api.Backend, prefix string) {
+	backend.Rclone.Path = prefix
+}
+
+func (rcloneBackend) TrimPrefix(backend *api.Backend, autoPrefix string) {
+	backend.Rclone.Path = trimAutoPrefix(backend.Rclone.Path, autoPrefix)
+}
+
+func (p rcloneBackend) Configure(backend *api.Backend, secret *core.Secret, autoPrefix, scratchDir string) (*BackendConfig, error) {
+	prefix, err := p.Prefix(backend, autoPrefix)
+	if err != nil {
+		return nil, err
+	}
+	configPath := filepath.Join(scratchDir, "rclone.conf")
+	if err := ioutil.WriteFile(configPath, secret.Data[RCLONE_CONFIG_DATA], 0600); err != nil {
+		return nil, err
+	}
+	return &BackendConfig{
+		Repository: fmt.Sprintf("rclone:%s:%s", backend.Rclone.Remote, prefix),
+		Env:        map[string]string{RCLONE_CONFIG: configPath},
+	}, nil
+}
+
+// writeSSHConfig writes private key, known_hosts and a ssh config for the sftp host in scratch dir.
+func writeSSHConfig(spec *api.SFTPSpec, secret *core.Secret, scratchDir string) (string, error) {
+	key, ok := secret.Data[SSH_PRIVATE_KEY]
+	if !ok {
+		return "", errors.New("missing ssh private key")
+	}
+
+	sshDir := filepath.Join(scratchDir, "ssh")
+	if err := os.MkdirAll(sshDir, 0700); err != nil {
+		return "", err
+	}
+	keyFile := filepath.Join(sshDir, "id_stash")
+	if err := ioutil.WriteFile(keyFile, key, 0600); err != nil {
+		return "", err
+	}
+
+	knownHostsFile := filepath.Join(sshDir, "known_hosts")
+	strictHostKeyChecking := "yes"
+	switch {
+	case spec.KnownHosts != "":
+		if err := ioutil.WriteFile(knownHostsFile, []byte(spec.KnownHosts), 0644); err != nil {
+			return "", err
+		}
+	case spec.InsecureSkipHostKeyVerification:
+		knownHostsFile = "/dev/null"
+		strictHostKeyChecking = "no"
+	default:
+		return "", errors.New("missing sftp knownHosts")
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "Host %s\n", spec.Host)
+	if spec.Port > 0 {
+		fmt.Fprintf(&buf, "  Port %d\n", spec.Port)
+	}
+	if spec.User != "" {
+		fmt.Fprintf(&buf, "  User %s\n", spec.User)
+	}
+	fmt.Fprintf(&buf, "  IdentityFile %s\n", keyFile)
+	fmt.Fprintf(&buf, "  IdentitiesOnly yes\n")
+	fmt.Fprintf(&buf, "  UserKnownHostsFile %s\n", knownHostsFile)
+	fmt.Fprintf(&buf, "  StrictHostKeyChecking %s\n", strictHostKeyChecking)
+	fmt.Fprintf(&buf, "  BatchMode yes\n")
+
+	configFile := filepath.Join(sshDir, "config")
+	if err := ioutil.WriteFile(configFile, buf.Bytes(), 0600); err != nil {
+		return "", err
+	}
+	return configFile, nil
+}
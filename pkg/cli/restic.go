@@ -1,39 +1,62 @@
 package cli
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/appscode/go/log"
 	api "github.com/appscode/stash/apis/stash/v1alpha1"
-	shell "github.com/codeskyblue/go-sh"
 	"github.com/pkg/errors"
 )
 
 const (
 	Exe = "/bin/restic"
+
+	// Maximum number of snapshot ids passed to a single restic command, so that the command line stays within the limits of the OS
+	maxSnapshotArgs = 500
+	// Maximum total length of snapshot ids passed to restic stats, which can't be split into several commands
+	maxStatsArgsLength = 1 << 20
+	// Minimum length of short snapshot ids, as printed by restic
+	shortIDLength = 8
 )
 
 type ResticWrapper struct {
-	sh          *shell.Session
-	scratchDir  string
-	enableCache bool
-	hostname    string
-	cacertFile  string
+	ctx             context.Context
+	progress        ProgressFunc
+	env             map[string]string
+	scratchDir      string
+	enableCache     bool
+	hostname        string
+	cacertFile      string
+	extendedOptions []string
 }
 
 func New(scratchDir string, enableCache bool, hostname string) *ResticWrapper {
-	ctrl := &ResticWrapper{
-		sh:          shell.NewSession(),
+	return &ResticWrapper{
+		ctx:         context.Background(),
+		env:         map[string]string{},
 		scratchDir:  scratchDir,
 		enableCache: enableCache,
 		hostname:    hostname,
 	}
-	ctrl.sh.SetDir(scratchDir)
-	ctrl.sh.ShowCMD = true
-	return ctrl
+}
+
+// WithContext returns a copy of w whose commands are killed when ctx is done.
+// The copy shares environment with w.
+func (w *ResticWrapper) WithContext(ctx context.Context) *ResticWrapper {
+	c := *w
+	c.ctx = ctx
+	return &c
 }
 
 type Snapshot struct {
@@ -52,40 +75,66 @@ func (w *ResticWrapper) ListSnapshots(snapshotIDs []string) ([]Snapshot, error)
 	result := make([]Snapshot, 0)
 	args := w.appendCacheDirFlag([]interface{}{"snapshots", "--json", "--quiet", "--no-lock"})
 	args = w.appendCaCertFlag(args)
+	args = w.appendExtendedOptions(args)
 	for _, id := range snapshotIDs {
 		args = append(args, id)
 	}
 
-	err := w.sh.Command(Exe, args...).UnmarshalJSON(&result)
+	out, err := w.run(Exe, args)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(out, &result)
 	return result, err
 }
 
 func (w *ResticWrapper) DeleteSnapshots(snapshotIDs []string) error {
 	args := w.appendCacheDirFlag([]interface{}{"forget", "--quiet", "--prune"})
 	args = w.appendCaCertFlag(args)
+	args = w.appendExtendedOptions(args)
 	for _, id := range snapshotIDs {
 		args = append(args, id)
 	}
 
-	return w.run(Exe, args)
+	_, err := w.run(Exe, args)
+	return err
 }
 
 func (w *ResticWrapper) InitRepositoryIfAbsent() error {
 	args := w.appendCacheDirFlag([]interface{}{"snapshots", "--json"})
 	args = w.appendCaCertFlag(args)
-	if err := w.run(Exe, args); err != nil {
+	args = w.appendExtendedOptions(args)
+	if _, err := w.run(Exe, args); err != nil {
+		// initialize only if restic confirmed that the repository is missing
+		if ErrorTypeOf(err) != ErrorRepositoryNotFound {
+			return err
+		}
 		args = w.appendCacheDirFlag([]interface{}{"init"})
 		args = w.appendCaCertFlag(args)
+		args = w.appendExtendedOptions(args)
 
-		return w.run(Exe, args)
+		_, err = w.run(Exe, args)
+		return err
 	}
 	return nil
 }
 
-func (w *ResticWrapper) Backup(resource *api.Restic, fg api.FileGroup) error {
-	args := []interface{}{"backup", fg.Path, "--force"}
+// BackupSummary is the summary message printed by restic backup in json mode.
+type BackupSummary struct {
+	FilesNew            int64   `json:"files_new"`
+	FilesChanged        int64   `json:"files_changed"`
+	FilesUnmodified     int64   `json:"files_unmodified"`
+	DataAdded           int64   `json:"data_added"`
+	TotalFilesProcessed int64   `json:"total_files_processed"`
+	TotalBytesProcessed int64   `json:"total_bytes_processed"`
+	TotalDuration       float64 `json:"total_duration"`
+	SnapshotID          string  `json:"snapshot_id"`
+}
+
+func (w *ResticWrapper) Backup(resource *api.Restic, fg api.FileGroup) (*BackupSummary, error) {
+	args := []interface{}{"backup", fg.Path, "--force", "--json"}
 	if w.hostname != "" {
-		args = append(args, "--hostname")
+		args = append(args, "--host")
 		args = append(args, w.hostname)
 	}
 	// add tags if any
@@ -95,66 +144,258 @@ func (w *ResticWrapper) Backup(resource *api.Restic, fg api.FileGroup) error {
 	}
 	args = w.appendCacheDirFlag(args)
 	args = w.appendCaCertFlag(args)
+	args = w.appendExtendedOptions(args)
 
-	return w.run(Exe, args)
+	out, err := w.run(Exe, args)
+	if err != nil {
+		return nil, err
+	}
+	return parseBackupSummary(out)
 }
 
-func (w *ResticWrapper) Forget(resource *api.Restic, fg api.FileGroup) error {
-	// Get retentionPolicy for fileGroup, ignore if not found
-	retentionPolicy := api.RetentionPolicy{}
-	for _, policy := range resource.Spec.RetentionPolicies {
-		if policy.Name == fg.RetentionPolicyName {
-			retentionPolicy = policy
-			break
+// parseBackupSummary finds the summary message in the json lines printed by restic backup.
+func parseBackupSummary(out []byte) (*BackupSummary, error) {
+	for _, line := range bytes.Split(out, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 || line[0] != '{' {
+			continue
+		}
+		var msg struct {
+			MessageType string `json:"message_type"`
 		}
+		if err := json.Unmarshal(line, &msg); err != nil || msg.MessageType != "summary" {
+			continue
+		}
+		summary := &BackupSummary{}
+		if err := json.Unmarshal(line, summary); err != nil {
+			return nil, err
+		}
+		return summary, nil
 	}
+	return nil, errors.New("summary not found in restic backup output")
+}
+
+// ForgetGroup is a group of snapshots evaluated by restic forget.
+type ForgetGroup struct {
+	Host    string       `json:"host"`
+	Paths   []string     `json:"paths"`
+	Tags    []string     `json:"tags"`
+	Keep    []Snapshot   `json:"keep"`
+	Remove  []Snapshot   `json:"remove"`
+	Reasons []KeepReason `json:"reasons"`
+}
+
+// KeepReason lists the rules of a retention policy that keep a snapshot, eg: "daily snapshot".
+type KeepReason struct {
+	Snapshot Snapshot `json:"snapshot"`
+	Matches  []string `json:"matches"`
+}
 
+// Forget removes snapshots of path that are not kept by policy and returns the kept and removed snapshots of each group.
+// Data of removed snapshots stays in the repository until Prune is run.
+func (w *ResticWrapper) Forget(path string, policy api.RetentionPolicy) ([]ForgetGroup, error) {
 	args := []interface{}{"forget"}
-	if retentionPolicy.KeepLast > 0 {
+	if policy.KeepLast > 0 {
 		args = append(args, string(api.KeepLast))
-		args = append(args, strconv.Itoa(retentionPolicy.KeepLast))
+		args = append(args, strconv.Itoa(policy.KeepLast))
 	}
-	if retentionPolicy.KeepHourly > 0 {
+	if policy.KeepHourly > 0 {
 		args = append(args, string(api.KeepHourly))
-		args = append(args, strconv.Itoa(retentionPolicy.KeepHourly))
+		args = append(args, strconv.Itoa(policy.KeepHourly))
 	}
-	if retentionPolicy.KeepDaily > 0 {
+	if policy.KeepDaily > 0 {
 		args = append(args, string(api.KeepDaily))
-		args = append(args, strconv.Itoa(retentionPolicy.KeepDaily))
+		args = append(args, strconv.Itoa(policy.KeepDaily))
 	}
-	if retentionPolicy.KeepWeekly > 0 {
+	if policy.KeepWeekly > 0 {
 		args = append(args, string(api.KeepWeekly))
-		args = append(args, strconv.Itoa(retentionPolicy.KeepWeekly))
+		args = append(args, strconv.Itoa(policy.KeepWeekly))
 	}
-	if retentionPolicy.KeepMonthly > 0 {
+	if policy.KeepMonthly > 0 {
 		args = append(args, string(api.KeepMonthly))
-		args = append(args, strconv.Itoa(retentionPolicy.KeepMonthly))
+		args = append(args, strconv.Itoa(policy.KeepMonthly))
 	}
-	if retentionPolicy.KeepYearly > 0 {
+	if policy.KeepYearly > 0 {
 		args = append(args, string(api.KeepYearly))
-		args = append(args, strconv.Itoa(retentionPolicy.KeepYearly))
+		args = append(args, strconv.Itoa(policy.KeepYearly))
 	}
-	for _, tag := range retentionPolicy.KeepTags {
+	for _, tag := range policy.KeepTags {
 		args = append(args, string(api.KeepTag))
 		args = append(args, tag)
 	}
-	if retentionPolicy.Prune {
-		args = append(args, "--prune")
+	for _, keep := range []struct {
+		strategy api.RetentionStrategy
+		duration string
+	}{
+		{api.KeepWithin, policy.KeepWithin},
+		{api.KeepWithinHourly, policy.KeepWithinHourly},
+		{api.KeepWithinDaily, policy.KeepWithinDaily},
+		{api.KeepWithinWeekly, policy.KeepWithinWeekly},
+		{api.KeepWithinMonthly, policy.KeepWithinMonthly},
+		{api.KeepWithinYearly, policy.KeepWithinYearly},
+	} {
+		if keep.duration != "" {
+			args = append(args, string(keep.strategy))
+			args = append(args, keep.duration)
+		}
+	}
+	if len(args) == 1 {
+		return nil, nil
+	}
+	if len(policy.GroupBy) > 0 {
+		keys := make([]string, 0, len(policy.GroupBy))
+		for _, key := range policy.GroupBy {
+			keys = append(keys, string(key))
+		}
+		args = append(args, "--group-by", strings.Join(keys, ","))
+	}
+	// nothing is removed in dry run, so it does not wait for or block backups and prune
+	if policy.DryRun {
+		args = append(args, "--dry-run", "--no-lock")
 	}
-	if retentionPolicy.DryRun {
-		args = append(args, "--dry-run")
+	args = append(args, "--path", path, "--json")
+	args = w.appendCacheDirFlag(args)
+	args = w.appendCaCertFlag(args)
+	args = w.appendExtendedOptions(args)
+
+	out, err := w.run(Exe, args)
+	if err != nil {
+		return nil, err
 	}
-	if len(args) > 1 {
-		args = w.appendCacheDirFlag(args)
+	return parseForgetGroups(out)
+}
+
+// ForgetSnapshots removes snapshots with the given ids. Data of removed snapshots stays in the repository until Prune is run.
+func (w *ResticWrapper) ForgetSnapshots(snapshotIDs []string) error {
+	for _, batch := range batchIDs(snapshotIDs, maxSnapshotArgs) {
+		args := w.appendCacheDirFlag([]interface{}{"forget", "--quiet"})
 		args = w.appendCaCertFlag(args)
+		args = w.appendExtendedOptions(args)
+		for _, id := range batch {
+			args = append(args, id)
+		}
 
-		return w.run(Exe, args)
+		if _, err := w.run(Exe, args); err != nil {
+			return err
+		}
 	}
 	return nil
 }
 
+type RepositoryStats struct {
+	TotalSize      uint64 `json:"total_size"`
+	TotalBlobCount uint64 `json:"total_blob_count"`
+	SnapshotsCount int    `json:"snapshots_count"`
+}
+
+// RawDataSize returns the size of data referenced by snapshots with the given ids, ie: the size of the
+// repository after the other snapshots are removed and the repository is pruned. Snapshots share data, so
+// the size can't be summed over several commands and all ids are passed to one command. Use ShortIDs to
+// fit ids of many snapshots into it.
+func (w *ResticWrapper) RawDataSize(snapshotIDs []string) (uint64, error) {
+	args, err := w.rawDataSizeArgs(snapshotIDs)
+	if err != nil {
+		return 0, err
+	}
+	out, err := w.run(Exe, args)
+	if err != nil {
+		return 0, err
+	}
+	var stats RepositoryStats
+	if err = json.Unmarshal(bytes.TrimSpace(out), &stats); err != nil {
+		return 0, err
+	}
+	return stats.TotalSize, nil
+}
+
+func (w *ResticWrapper) rawDataSizeArgs(snapshotIDs []string) ([]interface{}, error) {
+	length := 0
+	for _, id := range snapshotIDs {
+		length += len(id) + 1
+	}
+	if length > maxStatsArgsLength {
+		return nil, errors.Errorf("ids of %d snapshots are too long to get size of repository", len(snapshotIDs))
+	}
+	args := w.appendCacheDirFlag([]interface{}{"stats", "--mode", "raw-data", "--json", "--quiet", "--no-lock"})
+	args = w.appendCaCertFlag(args)
+	args = w.appendExtendedOptions(args)
+	for _, id := range snapshotIDs {
+		args = append(args, id)
+	}
+	return args, nil
+}
+
+// ShortIDs returns the shortest prefixes of ids that are unique among ids, but at least as long as short ids
+// printed by restic. restic accepts them instead of full ids. ids must contain all snapshots of the repository.
+func ShortIDs(ids []string) map[string]string {
+	sorted := append([]string(nil), ids...)
+	sort.Strings(sorted)
+	short := make(map[string]string, len(ids))
+	for i, id := range sorted {
+		n := shortIDLength
+		if i > 0 && commonPrefixLength(id, sorted[i-1]) >= n {
+			n = commonPrefixLength(id, sorted[i-1]) + 1
+		}
+		if i < len(sorted)-1 && commonPrefixLength(id, sorted[i+1]) >= n {
+			n = commonPrefixLength(id, sorted[i+1]) + 1
+		}
+		if n > len(id) {
+			n = len(id)
+		}
+		short[id] = id[:n]
+	}
+	return short
+}
+
+func commonPrefixLength(a, b string) int {
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+	return n
+}
+
+// batchIDs splits ids into batches of at most size ids.
+func batchIDs(ids []string, size int) [][]string {
+	var batches [][]string
+	for len(ids) > size {
+		batches = append(batches, ids[:size])
+		ids = ids[size:]
+	}
+	if len(ids) > 0 {
+		batches = append(batches, ids)
+	}
+	return batches
+}
+
+// parseForgetGroups finds the json array of groups printed by restic forget.
+func parseForgetGroups(out []byte) ([]ForgetGroup, error) {
+	for _, line := range bytes.Split(out, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 || line[0] != '[' {
+			continue
+		}
+		var groups []ForgetGroup
+		if err := json.Unmarshal(line, &groups); err != nil {
+			return nil, err
+		}
+		return groups, nil
+	}
+	return nil, nil
+}
+
+// Prune deletes data that is not referenced by any snapshot. It needs an exclusive lock of the repository.
+func (w *ResticWrapper) Prune() error {
+	args := w.appendCacheDirFlag([]interface{}{"prune"})
+	args = w.appendCaCertFlag(args)
+	args = w.appendExtendedOptions(args)
+
+	_, err := w.run(Exe, args)
+	return err
+}
+
 func (w *ResticWrapper) Restore(path, host string) error {
-	args := []interface{}{"restore"}
+	args := []interface{}{"restore", "--json"}
 	args = append(args, "latest") // TODO @ Dipta: Add support for specific snapshotID
 	args = append(args, "--path")
 	args = append(args, path) // source-path specified in restic fileGroup
@@ -168,15 +409,19 @@ func (w *ResticWrapper) Restore(path, host string) error {
 
 	args = w.appendCacheDirFlag(args)
 	args = w.appendCaCertFlag(args)
+	args = w.appendExtendedOptions(args)
 
-	return w.run(Exe, args)
+	_, err := w.run(Exe, args)
+	return err
 }
 
 func (w *ResticWrapper) Check() error {
 	args := w.appendCacheDirFlag([]interface{}{"check"})
 	args = w.appendCaCertFlag(args)
+	args = w.appendExtendedOptions(args)
 
-	return w.run(Exe, args)
+	_, err := w.run(Exe, args)
+	return err
 }
 
 func (w *ResticWrapper) appendCacheDirFlag(args []interface{}) []interface{} {
@@ -194,15 +439,78 @@ func (w *ResticWrapper) appendCaCertFlag(args []interface{}) []interface{} {
 	return args
 }
 
-func (w *ResticWrapper) run(cmd string, args []interface{}) error {
-	out, err := w.sh.Command(cmd, args...).CombinedOutput()
-	if err != nil {
-		log.Errorf("Error running command '%s %s' output:\n%s\n", cmd, args, string(out))
-		parts := strings.Split(strings.TrimSuffix(string(out), "\n"), "\n")
-		if len(parts) > 1 {
-			parts = parts[len(parts)-1:]
-			return errors.New(parts[0])
+func (w *ResticWrapper) appendExtendedOptions(args []interface{}) []interface{} {
+	for _, opt := range w.extendedOptions {
+		args = append(args, opt)
+	}
+	return args
+}
+
+// run executes cmd and returns its stdout without status messages. On failure, a *ResticError classified from stderr is returned.
+func (w *ResticWrapper) run(cmd string, args []interface{}) ([]byte, error) {
+	ctx := w.ctx
+	strArgs := make([]string, 0, len(args))
+	for _, arg := range args {
+		strArgs = append(strArgs, fmt.Sprint(arg))
+	}
+	log.Infoln("Running command:", cmd, strArgs)
+
+	stdout := &statusWriter{}
+	if w.progress != nil {
+		var stop func()
+		stdout.fn, stop = publishProgress(w.progress)
+		defer stop()
+	}
+	var stderr bytes.Buffer
+	c := exec.CommandContext(ctx, cmd, strArgs...)
+	c.Dir = w.scratchDir
+	c.Env = w.environ()
+	c.Stdout = stdout
+	c.Stderr = &stderr
+	err := c.Run()
+	if err == nil {
+		return stdout.Bytes(), nil
+	}
+
+	rerr := &ResticError{
+		Type:     classifyError(stderr.String()),
+		Command:  commandName(strArgs),
+		ExitCode: -1,
+		Stderr:   stderr.String(),
+		cause:    err,
+	}
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok {
+			rerr.ExitCode = status.ExitStatus()
 		}
 	}
-	return err
+	switch ctx.Err() {
+	case context.DeadlineExceeded:
+		rerr.Type = ErrorTimeout
+	case context.Canceled:
+		rerr.Type = ErrorCanceled
+	}
+	log.Errorf("Error running command '%s %s', type: %s, stderr:\n%s\n", cmd, strArgs, rerr.Type, rerr.Stderr)
+	return stdout.Bytes(), rerr
+}
+
+func (w *ResticWrapper) setEnv(key, value string) {
+	w.env[key] = value
+}
+
+// environ returns environment of the current process overridden by env of w.
+func (w *ResticWrapper) environ() []string {
+	env := os.Environ()
+	for k, v := range w.env {
+		env = append(env, k+"="+v)
+	}
+	return env
+}
+
+// commandName returns the restic sub-command in args.
+func commandName(args []string) string {
+	if len(args) > 0 {
+		return args[0]
+	}
+	return ""
 }
@@ -0,0 +1,154 @@
+package cli
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseBackupSummary(t *testing.T) {
+	cases := []struct {
+		name     string
+		out      string
+		expected *BackupSummary
+	}{
+		{
+			name: "status and summary",
+			out: `{"message_type":"status","percent_done":0,"total_files":1,"total_bytes":12}
+{"message_type":"status","percent_done":1,"total_files":3,"files_done":3,"total_bytes":2048,"bytes_done":2048}
+{"message_type":"summary","files_new":2,"files_changed":1,"files_unmodified":5,"dirs_new":0,"dirs_changed":1,"dirs_unmodified":2,"data_blobs":3,"tree_blobs":2,"data_added":1536,"total_files_processed":8,"total_bytes_processed":4096,"total_duration":1.25,"snapshot_id":"4f1c3b0e"}
+`,
+			expected: &BackupSummary{
+				FilesNew:            2,
+				FilesChanged:        1,
+				FilesUnmodified:     5,
+				DataAdded:           1536,
+				TotalFilesProcessed: 8,
+				TotalBytesProcessed: 4096,
+				TotalDuration:       1.25,
+				SnapshotID:          "4f1c3b0e",
+			},
+		},
+		{
+			name: "non json lines",
+			out: `using parent snapshot 1a2b3c4d
+  {"message_type":"summary","files_new":1,"data_added":10,"total_duration":0.5,"snapshot_id":"5e6f7a8b"}
+`,
+			expected: &BackupSummary{
+				FilesNew:      1,
+				DataAdded:     10,
+				TotalDuration: 0.5,
+				SnapshotID:    "5e6f7a8b",
+			},
+		},
+		{
+			name: "verbose status before summary",
+			out: `{"message_type":"verbose_status","action":"new","item":"/source/data/a","duration":0.1,"data_size":10}
+{"message_type":"summary","files_new":1,"snapshot_id":"9c0d1e2f"}`,
+			expected: &BackupSummary{
+				FilesNew:   1,
+				SnapshotID: "9c0d1e2f",
+			},
+		},
+		{
+			name: "no summary",
+			out: `{"message_type":"status","percent_done":0.5}
+`,
+		},
+		{
+			name: "empty",
+			out:  "",
+		},
+	}
+	for _, c := range cases {
+		summary, err := parseBackupSummary([]byte(c.out))
+		if c.expected == nil {
+			if err == nil {
+				t.Errorf("%s: expected error, got summary %+v", c.name, summary)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %s", c.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(summary, c.expected) {
+			t.Errorf("%s: expected %+v, got %+v", c.name, c.expected, summary)
+		}
+	}
+}
+
+func TestBatchIDs(t *testing.T) {
+	cases := []struct {
+		ids      []string
+		size     int
+		expected [][]string
+	}{
+		{nil, 2, nil},
+		{[]string{"a"}, 2, [][]string{{"a"}}},
+		{[]string{"a", "b"}, 2, [][]string{{"a", "b"}}},
+		{[]string{"a", "b", "c", "d", "e"}, 2, [][]string{{"a", "b"}, {"c", "d"}, {"e"}}},
+	}
+	for _, c := range cases {
+		if got := batchIDs(c.ids, c.size); !reflect.DeepEqual(got, c.expected) {
+			t.Errorf("%v in batches of %d: expected %v, got %v", c.ids, c.size, c.expected, got)
+		}
+	}
+}
+
+func TestShortIDs(t *testing.T) {
+	ids := []string{
+		"4f1c3b0e9a8b7c6d5e4f3a2b1c0d9e8f7a6b5c4d3e2f1a0b9c8d7e6f5a4b3c2d",
+		"4f1c3b0e9a000000000000000000000000000000000000000000000000000000",
+		"5e6f7a8b00000000000000000000000000000000000000000000000000000000",
+		"9c0d",
+	}
+	expected := map[string]string{
+		ids[0]: "4f1c3b0e9a8",
+		ids[1]: "4f1c3b0e9a0",
+		ids[2]: "5e6f7a8b",
+		ids[3]: "9c0d",
+	}
+	if got := ShortIDs(ids); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+}
+
+func TestRawDataSizeArgs(t *testing.T) {
+	w := New("/tmp", false, "stash-demo")
+
+	// more snapshots than passed to other commands at once, all must be passed to one stats command
+	n := 2*maxSnapshotArgs + 1
+	ids := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		ids = append(ids, fmt.Sprintf("%08x%s", i, strings.Repeat("0", 56)))
+	}
+	short := ShortIDs(ids)
+	shortIDs := make([]string, 0, n)
+	for _, id := range ids {
+		shortIDs = append(shortIDs, short[id])
+	}
+	args, err := w.rawDataSizeArgs(shortIDs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(args) < n {
+		t.Fatalf("expected %d short ids in one command, got %d args", n, len(args))
+	}
+	for i, arg := range args[len(args)-n:] {
+		if arg != shortIDs[i] || len(shortIDs[i]) != shortIDLength {
+			t.Errorf("expected short id %s at %d, got %v", shortIDs[i], i, arg)
+			break
+		}
+	}
+
+	// ids that do not fit into one command are refused instead of summing sizes of several commands
+	long := make([]string, maxStatsArgsLength/64+1)
+	for i := range long {
+		long[i] = strings.Repeat("a", 64)
+	}
+	if _, err := w.rawDataSizeArgs(long); err == nil {
+		t.Errorf("expected error for ids of %d snapshots", len(long))
+	}
+}
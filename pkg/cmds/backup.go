@@ -67,7 +67,7 @@ func NewCmdBackup() *cobra.Command {
 			}
 			opt.ScratchDir = strings.TrimSuffix(opt.ScratchDir, "/") // make ScratchDir in setup()
 
-			ctrl := backup.New(kubeClient, stashClient, opt)
+			ctrl := backup.New(kubeClient, stashClient, config, opt)
 
 			if opt.RunViaCron {
 				log.Infoln("Running backup periodically via cron")
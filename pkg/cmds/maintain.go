@@ -0,0 +1,48 @@
+package cmds
+
+import (
+	"github.com/appscode/go/log"
+	"github.com/appscode/kutil/meta"
+	cs "github.com/appscode/stash/client/clientset/versioned"
+	"github.com/appscode/stash/pkg/maintenance"
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+func NewCmdMaintain() *cobra.Command {
+	var (
+		masterURL      string
+		kubeconfigPath string
+		opt            = maintenance.Options{
+			Namespace:  meta.Namespace(),
+			ScratchDir: "/tmp",
+		}
+	)
+
+	cmd := &cobra.Command{
+		Use:               "maintain",
+		Short:             "Apply retention policies to a restic repository",
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			config, err := clientcmd.BuildConfigFromFlags(masterURL, kubeconfigPath)
+			if err != nil {
+				log.Fatalf("Could not get Kubernetes config: %s", err)
+			}
+			kubeClient := kubernetes.NewForConfigOrDie(config)
+			stashClient := cs.NewForConfigOrDie(config)
+
+			c := maintenance.New(kubeClient, stashClient, opt)
+			if err = c.Run(); err != nil {
+				log.Fatal(err)
+			}
+			log.Infoln("Exiting stash maintain")
+		},
+	}
+	cmd.Flags().StringVar(&masterURL, "master", masterURL, "The address of the Kubernetes API server (overrides any value in kubeconfig)")
+	cmd.Flags().StringVar(&kubeconfigPath, "kubeconfig", kubeconfigPath, "Path to kubeconfig file with authorization information (the master location is set by the master flag).")
+	cmd.Flags().StringVar(&opt.RepositoryName, "repo-name", opt.RepositoryName, "Name of the Repository CRD.")
+	cmd.Flags().StringVar(&opt.ScratchDir, "scratch-dir", opt.ScratchDir, "Directory used to store temporary files. Use an `emptyDir` in Kubernetes.")
+
+	return cmd
+}
@@ -15,6 +15,7 @@ func NewCmdRecover() *cobra.Command {
 		masterURL      string
 		kubeconfigPath string
 		recoveryName   string
+		pushgatewayURL string
 	)
 
 	cmd := &cobra.Command{
@@ -29,13 +30,14 @@ func NewCmdRecover() *cobra.Command {
 			kubeClient := kubernetes.NewForConfigOrDie(config)
 			stashClient := cs.NewForConfigOrDie(config)
 
-			c := recovery.New(kubeClient, stashClient, meta.Namespace(), recoveryName)
+			c := recovery.New(kubeClient, stashClient, config, meta.Namespace(), recoveryName, pushgatewayURL)
 			c.Run()
 		},
 	}
 	cmd.Flags().StringVar(&masterURL, "master", masterURL, "The address of the Kubernetes API server (overrides any value in kubeconfig)")
 	cmd.Flags().StringVar(&kubeconfigPath, "kubeconfig", kubeconfigPath, "Path to kubeconfig file with authorization information (the master location is set by the master flag).")
 	cmd.Flags().StringVar(&recoveryName, "recovery-name", recoveryName, "Name of the Recovery CRD.")
+	cmd.Flags().StringVar(&pushgatewayURL, "pushgateway-url", pushgatewayURL, "URL of Prometheus pushgateway used to cache recovery metrics")
 
 	return cmd
 }
@@ -0,0 +1,80 @@
+package cmds
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/appscode/kutil/meta"
+	api "github.com/appscode/stash/apis/stash/v1alpha1"
+	cs "github.com/appscode/stash/client/clientset/versioned"
+	"github.com/appscode/stash/pkg/maintenance"
+	"github.com/ghodss/yaml"
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+func NewCmdRetentionPreview() *cobra.Command {
+	var (
+		masterURL      string
+		kubeconfigPath string
+		policyFile     string
+		opt            = maintenance.Options{
+			Namespace:  meta.Namespace(),
+			ScratchDir: "/tmp",
+		}
+	)
+
+	cmd := &cobra.Command{
+		Use:               "retention-preview",
+		Short:             "Show which snapshots retention policies would keep or remove",
+		DisableAutoGenTag: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config, err := clientcmd.BuildConfigFromFlags(masterURL, kubeconfigPath)
+			if err != nil {
+				return err
+			}
+			if opt.RepositoryName == "" {
+				return fmt.Errorf("repository name not found")
+			}
+
+			var policy *api.RetentionPolicy
+			if policyFile != "" {
+				data, err := ioutil.ReadFile(policyFile)
+				if err != nil {
+					return err
+				}
+				policy = &api.RetentionPolicy{}
+				if err = yaml.Unmarshal(data, policy); err != nil {
+					return err
+				}
+				if err = policy.IsValid(); err != nil {
+					return err
+				}
+			}
+
+			kubeClient := kubernetes.NewForConfigOrDie(config)
+			stashClient := cs.NewForConfigOrDie(config)
+
+			c := maintenance.New(kubeClient, stashClient, opt)
+			decisions, err := c.Preview(policy)
+			if err != nil {
+				return err
+			}
+			jsonDecisions, err := json.MarshalIndent(decisions, "", "    ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(jsonDecisions))
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&masterURL, "master", masterURL, "The address of the Kubernetes API server (overrides any value in kubeconfig)")
+	cmd.Flags().StringVar(&kubeconfigPath, "kubeconfig", kubeconfigPath, "Path to kubeconfig file with authorization information (the master location is set by the master flag).")
+	cmd.Flags().StringVar(&opt.RepositoryName, "repo-name", opt.RepositoryName, "Name of the Repository CRD.")
+	cmd.Flags().StringVar(&policyFile, "policy-file", policyFile, "Path to a yaml or json retention policy evaluated for every file group instead of the retention policies of the Restic.")
+	cmd.Flags().StringVar(&opt.ScratchDir, "scratch-dir", opt.ScratchDir, "Directory used to store temporary files. Use an `emptyDir` in Kubernetes.")
+
+	return cmd
+}
@@ -60,6 +60,8 @@ func NewRootCmd() *cobra.Command {
 	rootCmd.AddCommand(NewCmdScaleDown())
 	rootCmd.AddCommand(NewCmdSnapshots())
 	rootCmd.AddCommand(NewCmdForget())
+	rootCmd.AddCommand(NewCmdMaintain())
+	rootCmd.AddCommand(NewCmdRetentionPreview())
 
 	return rootCmd
 }
@@ -3,6 +3,7 @@ package cmds
 import (
 	"github.com/appscode/go/log"
 	"github.com/appscode/kutil/meta"
+	cs "github.com/appscode/stash/client/clientset/versioned"
 	"github.com/appscode/stash/pkg/scale"
 	"github.com/spf13/cobra"
 	"k8s.io/client-go/kubernetes"
@@ -28,8 +29,9 @@ func NewCmdScaleDown() *cobra.Command {
 				log.Fatalf("Could not get Kubernetes config: %s", err)
 			}
 			kubeClient := kubernetes.NewForConfigOrDie(config)
+			stashClient := cs.NewForConfigOrDie(config)
 
-			ctrl := scale.New(kubeClient, opt)
+			ctrl := scale.New(kubeClient, stashClient, opt)
 			err = ctrl.ScaleDownWorkload()
 			if err != nil {
 				log.Fatal(err)
@@ -40,6 +42,7 @@ func NewCmdScaleDown() *cobra.Command {
 	cmd.Flags().StringVar(&masterURL, "master", masterURL, "The address of the Kubernetes API server (overrides any value in kubeconfig)")
 	cmd.Flags().StringVar(&kubeconfigPath, "kubeconfig", kubeconfigPath, "Path to kubeconfig file with authorization information (the master location is set by the master flag).")
 	cmd.Flags().StringVar(&opt.Selector, "selector", opt.Selector, "Label used to select Restic's workload")
+	cmd.Flags().StringVar(&opt.ResticName, "restic-name", opt.ResticName, "Name of the Restic whose blackout windows are respected")
 
 	return cmd
 }
@@ -25,6 +25,11 @@ type ControllerOptions struct {
 	QPS            float64
 	Burst          int
 	ResyncPeriod   time.Duration
+
+	MaxConcurrentBackups           int
+	MaxConcurrentBackupsPerNode    int
+	MaxConcurrentBackupsPerBackend int
+	BackupDispatchTimeout          time.Duration
 }
 
 func NewControllerOptions() *ControllerOptions {
@@ -38,6 +43,8 @@ func NewControllerOptions() *ControllerOptions {
 		QPS:            100,
 		Burst:          100,
 		ResyncPeriod:   10 * time.Minute,
+
+		BackupDispatchTimeout: 6 * time.Hour,
 	}
 }
 
@@ -51,6 +58,11 @@ func (s *ControllerOptions) AddGoFlags(fs *flag.FlagSet) {
 	fs.Float64Var(&s.QPS, "qps", s.QPS, "The maximum QPS to the master from this client")
 	fs.IntVar(&s.Burst, "burst", s.Burst, "The maximum burst for throttle")
 	fs.DurationVar(&s.ResyncPeriod, "resync-period", s.ResyncPeriod, "If non-zero, will re-list this often. Otherwise, re-list will be delayed aslong as possible (until the upstream source closes the watch or times out.")
+
+	fs.IntVar(&s.MaxConcurrentBackups, "max-concurrent-backups", s.MaxConcurrentBackups, "Maximum number of backups dispatched by operator at once in the cluster. 0 means no limit.")
+	fs.IntVar(&s.MaxConcurrentBackupsPerNode, "max-concurrent-backups-per-node", s.MaxConcurrentBackupsPerNode, "Maximum number of backups dispatched by operator at once on a node. 0 means no limit.")
+	fs.IntVar(&s.MaxConcurrentBackupsPerBackend, "max-concurrent-backups-per-backend", s.MaxConcurrentBackupsPerBackend, "Maximum number of backups dispatched by operator at once to a backend bucket or server. 0 means no limit.")
+	fs.DurationVar(&s.BackupDispatchTimeout, "backup-dispatch-timeout", s.BackupDispatchTimeout, "Duration after which a backup dispatched by operator that has not completed is considered lost.")
 }
 
 func (s *ControllerOptions) AddFlags(fs *pflag.FlagSet) {
@@ -69,6 +81,10 @@ func (s *ControllerOptions) ApplyTo(cfg *controller.ControllerConfig) error {
 	cfg.NumThreads = s.NumThreads
 	cfg.OpsAddress = s.OpsAddress
 	cfg.ResyncPeriod = s.ResyncPeriod
+	cfg.MaxConcurrentBackups = s.MaxConcurrentBackups
+	cfg.MaxConcurrentBackupsPerNode = s.MaxConcurrentBackupsPerNode
+	cfg.MaxConcurrentBackupsPerBackend = s.MaxConcurrentBackupsPerBackend
+	cfg.BackupDispatchTimeout = s.BackupDispatchTimeout
 
 	cfg.ClientConfig.QPS = float32(s.QPS)
 	cfg.ClientConfig.Burst = s.Burst
@@ -23,6 +23,12 @@ type Config struct {
 	NumThreads     int
 	OpsAddress     string
 	ResyncPeriod   time.Duration
+	// Limits of backups dispatched by operator, 0 means no limit
+	MaxConcurrentBackups           int
+	MaxConcurrentBackupsPerNode    int
+	MaxConcurrentBackupsPerBackend int
+	// Duration after which a dispatched backup that has not completed is considered lost
+	BackupDispatchTimeout time.Duration
 }
 
 type ControllerConfig struct {
@@ -53,6 +59,8 @@ func (c *ControllerConfig) New() (*StashController, error) {
 		kubeInformerFactory:  informers.NewFilteredSharedInformerFactory(c.KubeClient, c.ResyncPeriod, core.NamespaceAll, tweakListOptions),
 		stashInformerFactory: stashinformers.NewSharedInformerFactory(c.StashClient, c.ResyncPeriod),
 		recorder:             eventer.NewEventRecorder(c.KubeClient, "stash-controller"),
+		nextBackups:          map[string]time.Time{},
+		dueBackups:           map[string]time.Time{},
 	}
 
 	if err := ctrl.ensureCustomResourceDefinitions(); err != nil {
@@ -66,6 +74,7 @@ func (c *ControllerConfig) New() (*StashController, error) {
 
 	ctrl.initNamespaceWatcher()
 	ctrl.initResticWatcher()
+	ctrl.initRepositoryWatcher()
 	ctrl.initRecoveryWatcher()
 	ctrl.initDeploymentWatcher()
 	ctrl.initDaemonSetWatcher()
@@ -3,6 +3,7 @@ package controller
 import (
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/appscode/go/log"
 	crdutils "github.com/appscode/kutil/apiextensions/v1beta1"
@@ -17,6 +18,7 @@ import (
 	crd_api "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
 	crd_cs "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/typed/apiextensions/v1beta1"
 	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	apps_listers "k8s.io/client-go/listers/apps/v1beta1"
@@ -46,6 +48,15 @@ type StashController struct {
 	rstInformer cache.SharedIndexInformer
 	rstLister   stash_listers.ResticLister
 
+	// Repository
+	repoQueue    *queue.Worker
+	repoInformer cache.SharedIndexInformer
+	repoLister   stash_listers.RepositoryLister
+
+	// Backups of Restics scheduled by operator, keyed by Repository
+	nextBackups map[string]time.Time
+	dueBackups  map[string]time.Time
+
 	// Recovery
 	recQueue    *queue.Worker
 	recInformer cache.SharedIndexInformer
@@ -87,6 +98,7 @@ func (c *StashController) ensureCustomResourceDefinitions() error {
 		api.Restic{}.CustomResourceDefinition(),
 		api.Recovery{}.CustomResourceDefinition(),
 		api.Repository{}.CustomResourceDefinition(),
+		api.BackupSession{}.CustomResourceDefinition(),
 	}
 	return crdutils.RegisterCRDs(c.crdClient, crds)
 }
@@ -113,6 +125,7 @@ func (c *StashController) RunInformers(stopCh <-chan struct{}) {
 	}
 
 	c.rstQueue.Run(stopCh)
+	c.repoQueue.Run(stopCh)
 	c.recQueue.Run(stopCh)
 	c.dpQueue.Run(stopCh)
 	c.dsQueue.Run(stopCh)
@@ -120,6 +133,9 @@ func (c *StashController) RunInformers(stopCh <-chan struct{}) {
 	c.rcQueue.Run(stopCh)
 	c.rsQueue.Run(stopCh)
 	c.jobQueue.Run(stopCh)
+
+	go wait.Until(c.checkBackupOverdue, backupOverdueCheckInterval, stopCh)
+	go wait.Until(c.scheduleBackups, backupDispatchInterval, stopCh)
 }
 
 func (c *StashController) RunOpsServer(stopCh <-chan struct{}) error {
@@ -0,0 +1,166 @@
+package controller
+
+import (
+	"fmt"
+
+	batch_util "github.com/appscode/kutil/batch/v1beta1"
+	core_util "github.com/appscode/kutil/core/v1"
+	"github.com/appscode/kutil/tools/analytics"
+	api "github.com/appscode/stash/apis/stash/v1alpha1"
+	"github.com/appscode/stash/pkg/docker"
+	"github.com/appscode/stash/pkg/util"
+	batch "k8s.io/api/batch/v1beta1"
+	core "k8s.io/api/core/v1"
+	rbac "k8s.io/api/rbac/v1"
+	kerr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/reference"
+)
+
+const (
+	DefaultMaintenanceSchedule = "@daily"
+)
+
+// needsMaintenance returns true if a FileGroup of restic uses a retention policy or restic limits the repository size.
+func needsMaintenance(restic *api.Restic) bool {
+	if restic.Spec.MaxRepositorySize != nil {
+		return true
+	}
+	for _, fg := range restic.Spec.FileGroups {
+		if fg.RetentionPolicyName != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// EnsureMaintenanceCronJob creates the CronJob that applies retention policies and maxRepositorySize of restic to repository.
+// The CronJob is owned by repository.
+func (c *StashController) EnsureMaintenanceCronJob(restic *api.Restic, repository *api.Repository) error {
+	image := docker.Docker{
+		Registry: c.DockerRegistry,
+		Image:    docker.ImageStash,
+		Tag:      c.StashImageTag,
+	}
+
+	meta := metav1.ObjectMeta{
+		Name:      util.MaintenanceCronPrefix + repository.Name,
+		Namespace: repository.Namespace,
+	}
+
+	cronJob, _, err := batch_util.CreateOrPatchCronJob(c.kubeClient, meta, func(in *batch.CronJob) *batch.CronJob {
+		// set repository as cron-job owner
+		in.OwnerReferences = []metav1.OwnerReference{
+			{
+				APIVersion: api.SchemeGroupVersion.String(),
+				Kind:       api.ResourceKindRepository,
+				Name:       repository.Name,
+				UID:        repository.UID,
+			},
+		}
+
+		if in.Labels == nil {
+			in.Labels = map[string]string{}
+		}
+		in.Labels["app"] = util.AppLabelStash
+		in.Labels[util.AnnotationRestic] = restic.Name
+		in.Labels[util.AnnotationRepository] = repository.Name
+		in.Labels[util.AnnotationOperation] = util.OperationMaintenance
+
+		// spec
+		in.Spec.Schedule = restic.Spec.MaintenanceSchedule
+		if in.Spec.Schedule == "" {
+			in.Spec.Schedule = DefaultMaintenanceSchedule
+		}
+		// backups and other maintenance jobs are waited for using the repository lock
+		in.Spec.ConcurrencyPolicy = batch.ForbidConcurrent
+		if in.Spec.JobTemplate.Labels == nil {
+			in.Spec.JobTemplate.Labels = map[string]string{}
+		}
+		in.Spec.JobTemplate.Labels["app"] = util.AppLabelStash
+		in.Spec.JobTemplate.Labels[util.AnnotationRestic] = restic.Name
+		in.Spec.JobTemplate.Labels[util.AnnotationRepository] = repository.Name
+		in.Spec.JobTemplate.Labels[util.AnnotationOperation] = util.OperationMaintenance
+
+		volumeMounts := []core.VolumeMount{
+			{
+				Name:      util.ScratchDirVolumeName,
+				MountPath: "/tmp",
+			},
+		}
+		volumes := []core.Volume{
+			{
+				Name: util.ScratchDirVolumeName,
+				VolumeSource: core.VolumeSource{
+					EmptyDir: &core.EmptyDirVolumeSource{},
+				},
+			},
+		}
+		// local backend, subPath of the volume includes the prefix of the workload
+		if vol, mnt := util.BackendVolumeAndMount(repository.Spec.Backend); vol != nil {
+			volumeMounts = append(volumeMounts, *mnt)
+			volumes = append(volumes, *vol)
+		}
+
+		in.Spec.JobTemplate.Spec.Template.Spec.Containers = core_util.UpsertContainer(
+			in.Spec.JobTemplate.Spec.Template.Spec.Containers,
+			core.Container{
+				Name:  util.StashContainer,
+				Image: image.ToContainerImage(),
+				Args: append([]string{
+					"maintain",
+					"--repo-name=" + repository.Name,
+					fmt.Sprintf("--enable-analytics=%v", util.EnableAnalytics),
+				}, util.LoggerOptions.ToFlags()...),
+				Env: []core.EnvVar{
+					{
+						Name:  analytics.Key,
+						Value: util.AnalyticsClientID,
+					},
+				},
+				VolumeMounts: volumeMounts,
+			})
+		for _, vol := range volumes {
+			in.Spec.JobTemplate.Spec.Template.Spec.Volumes = core_util.UpsertVolume(in.Spec.JobTemplate.Spec.Template.Spec.Volumes, vol)
+		}
+		in.Spec.JobTemplate.Spec.Template.Spec.ImagePullSecrets = restic.Spec.ImagePullSecrets
+		// hostPath backend of DaemonSet is only available on the node of the repository
+		in.Spec.JobTemplate.Spec.Template.Spec.NodeName = repository.Labels["node-name"]
+
+		in.Spec.JobTemplate.Spec.Template.Spec.RestartPolicy = core.RestartPolicyNever
+		if c.EnableRBAC {
+			in.Spec.JobTemplate.Spec.Template.Spec.ServiceAccountName = in.Name
+		}
+		return in
+	})
+	if err != nil {
+		return err
+	}
+
+	if c.EnableRBAC {
+		ref, err := reference.GetReference(scheme.Scheme, cronJob)
+		if err != nil {
+			return err
+		}
+		// maintenance job needs the same permissions as recovery job
+		if err = c.ensureRecoveryRBAC(ref); err != nil {
+			return fmt.Errorf("error ensuring rbac for maintenance cron job %s, reason: %s\n", meta.Name, err)
+		}
+		if err = c.ensurePodsRBAC(ref, GetPodsRoleName(meta.Name), meta.Name, []rbac.PolicyRule{LockPodsRule}); err != nil {
+			return fmt.Errorf("error ensuring rbac for maintenance cron job %s, reason: %s\n", meta.Name, err)
+		}
+	}
+	return nil
+}
+
+func (c *StashController) EnsureMaintenanceCronJobDeleted(repository *api.Repository) error {
+	deletePolicy := metav1.DeletePropagationBackground
+	err := c.kubeClient.BatchV1beta1().CronJobs(repository.Namespace).Delete(util.MaintenanceCronPrefix+repository.Name, &metav1.DeleteOptions{
+		PropagationPolicy: &deletePolicy,
+	})
+	if err != nil && !kerr.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
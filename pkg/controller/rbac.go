@@ -10,7 +10,9 @@ import (
 	core "k8s.io/api/core/v1"
 	extensions "k8s.io/api/extensions/v1beta1"
 	rbac "k8s.io/api/rbac/v1"
+	kerr "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
 )
 
 const (
@@ -104,13 +106,87 @@ func (c *StashController) ensureSidecarClusterRole() error {
 			},
 			{
 				APIGroups: []string{rbac.GroupName},
-				Resources: []string{"clusterroles", "roles", "rolebindings"},
+				Resources: []string{"clusterroles"},
 				Verbs:     []string{"get", "create"},
 			},
+			{
+				// rbac of check jobs is created or patched by the sidecar
+				APIGroups: []string{rbac.GroupName},
+				Resources: []string{"roles", "rolebindings"},
+				Verbs:     []string{"get", "create", "patch"},
+			},
 			{
 				APIGroups: []string{core.GroupName},
 				Resources: []string{"serviceaccounts"},
-				Verbs:     []string{"get", "create"},
+				Verbs:     []string{"get", "create", "patch"},
+			},
+		}
+		return in
+	})
+	return err
+}
+
+func GetPodsRoleName(name string) string {
+	return name + "-pods"
+}
+
+// LockPodsRule allows to list pods, so that locks of running pods of the workload are not removed as stale.
+var LockPodsRule = rbac.PolicyRule{
+	APIGroups: []string{core.GroupName},
+	Resources: []string{"pods"},
+	Verbs:     []string{"list"},
+}
+
+func (c *StashController) ensurePodsRBAC(resource *core.ObjectReference, name, sa string, rules []rbac.PolicyRule) error {
+	if len(rules) == 0 {
+		return c.ensurePodsRBACDeleted(metav1.ObjectMeta{
+			Name:      name,
+			Namespace: resource.Namespace,
+		})
+	}
+	return EnsurePodsRBAC(c.kubeClient, resource, name, sa, rules)
+}
+
+// EnsurePodsRBAC grants sa the rules on pods, that are not part of SidecarClusterRole, by a Role and RoleBinding in the
+// namespace of resource. Both are named name and owned by resource.
+func EnsurePodsRBAC(kubeClient kubernetes.Interface, resource *core.ObjectReference, name, sa string, rules []rbac.PolicyRule) error {
+	meta := metav1.ObjectMeta{
+		Name:      name,
+		Namespace: resource.Namespace,
+	}
+	_, _, err := rbac_util.CreateOrPatchRole(kubeClient, meta, func(in *rbac.Role) *rbac.Role {
+		in.ObjectMeta = core_util.EnsureOwnerReference(in.ObjectMeta, resource)
+
+		if in.Labels == nil {
+			in.Labels = map[string]string{}
+		}
+		in.Labels["app"] = "stash"
+
+		in.Rules = rules
+		return in
+	})
+	if err != nil {
+		return err
+	}
+
+	_, _, err = rbac_util.CreateOrPatchRoleBinding(kubeClient, meta, func(in *rbac.RoleBinding) *rbac.RoleBinding {
+		in.ObjectMeta = core_util.EnsureOwnerReference(in.ObjectMeta, resource)
+
+		if in.Labels == nil {
+			in.Labels = map[string]string{}
+		}
+		in.Labels["app"] = "stash"
+
+		in.RoleRef = rbac.RoleRef{
+			APIGroup: rbac.GroupName,
+			Kind:     "Role",
+			Name:     meta.Name,
+		}
+		in.Subjects = []rbac.Subject{
+			{
+				Kind:      "ServiceAccount",
+				Name:      sa,
+				Namespace: resource.Namespace,
 			},
 		}
 		return in
@@ -118,6 +194,62 @@ func (c *StashController) ensureSidecarClusterRole() error {
 	return err
 }
 
+func (c *StashController) ensurePodsRBACDeleted(meta metav1.ObjectMeta) error {
+	err := c.kubeClient.RbacV1().RoleBindings(meta.Namespace).Delete(meta.Name, &metav1.DeleteOptions{})
+	if err != nil && !kerr.IsNotFound(err) {
+		return err
+	}
+	err = c.kubeClient.RbacV1().Roles(meta.Namespace).Delete(meta.Name, &metav1.DeleteOptions{})
+	if err != nil && !kerr.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// sidecarPodsRules returns the rules on pods needed by the sidecar of restic and its check jobs. Exec hooks run in the pod
+// of the sidecar, but pods of workloads have generated names, so exec is granted for pods of the namespace of the workload.
+func sidecarPodsRules(restic *api.Restic) []rbac.PolicyRule {
+	rules := []rbac.PolicyRule{LockPodsRule}
+	if restic.Spec.Hooks != nil && (hasExecHook(restic.Spec.Hooks.PreBackup) || hasExecHook(restic.Spec.Hooks.PostBackup)) {
+		rules = append(rules, rbac.PolicyRule{
+			APIGroups: []string{core.GroupName},
+			Resources: []string{"pods/exec"},
+			Verbs:     []string{"create"},
+		})
+	}
+	return rules
+}
+
+func hasExecHook(hooks []api.Hook) bool {
+	for _, hook := range hooks {
+		if hook.Exec != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// recoveryPodsRules returns the rules on pods needed by the recovery job, exec is only granted for the pods of its hooks.
+func recoveryPodsRules(recovery *api.Recovery) []rbac.PolicyRule {
+	var pods []string
+	for _, hook := range recovery.Spec.PostRestoreHooks {
+		if hook.Exec != nil {
+			pods = append(pods, hook.Exec.Pod)
+		}
+	}
+	if len(pods) == 0 {
+		return nil
+	}
+	return []rbac.PolicyRule{
+		{
+			APIGroups:     []string{core.GroupName},
+			Resources:     []string{"pods/exec"},
+			ResourceNames: pods,
+			Verbs:         []string{"create"},
+		},
+	}
+}
+
 // use scaledownjob-role, service-account and role-binding name same as job name
 // set job as owner of role, service-account and role-binding
 func (c *StashController) ensureScaledownJoblRBAC(resource *core.ObjectReference) error {
@@ -140,6 +272,16 @@ func (c *StashController) ensureScaledownJoblRBAC(resource *core.ObjectReference
 				Resources: []string{"pods"},
 				Verbs:     []string{"get", "list", "delete", "deletecollection"},
 			},
+			{
+				APIGroups: []string{api.SchemeGroupVersion.Group},
+				Resources: []string{"restics"},
+				Verbs:     []string{"get"},
+			},
+			{
+				APIGroups: []string{core.GroupName},
+				Resources: []string{"events"},
+				Verbs:     []string{"create"},
+			},
 		}
 		return in
 	})
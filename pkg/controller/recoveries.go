@@ -161,6 +161,9 @@ func (c *StashController) runRecoveryJob(rec *api.Recovery) error {
 		if err := c.ensureRecoveryRBAC(ref); err != nil {
 			return fmt.Errorf("error ensuring rbac for recovery job %s, reason: %s\n", job.Name, err)
 		}
+		if err := c.ensurePodsRBAC(ref, GetPodsRoleName(job.Name), job.Name, recoveryPodsRules(rec)); err != nil {
+			return fmt.Errorf("error ensuring rbac for hooks of recovery job %s, reason: %s\n", job.Name, err)
+		}
 	}
 
 	log.Infoln("Recovery job created:", job.Name)
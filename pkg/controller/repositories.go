@@ -0,0 +1,212 @@
+package controller
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/appscode/go/log"
+	"github.com/appscode/kutil/tools/queue"
+	api "github.com/appscode/stash/apis/stash/v1alpha1"
+	stash_util "github.com/appscode/stash/client/clientset/versioned/typed/stash/v1alpha1/util"
+	"github.com/appscode/stash/pkg/eventer"
+	"github.com/golang/glog"
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/robfig/cron.v2"
+	core "k8s.io/api/core/v1"
+	kerr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/reference"
+)
+
+const (
+	// Interval between checks for overdue backups
+	backupOverdueCheckInterval = time.Minute
+
+	BackupOverdueReasonMissedSchedule  = "MissedSchedule"
+	BackupOverdueReasonInvalidSchedule = "InvalidSchedule"
+	BackupOverdueReasonOnSchedule      = "OnSchedule"
+	BackupOverdueReasonPaused          = "Paused"
+)
+
+var repositorySecondsSinceLastSuccessfulBackup = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: "stash",
+	Subsystem: "repository",
+	Name:      "seconds_since_last_successful_backup",
+	Help:      "Seconds since last successful backup of Repository, counted from creation of Repository if no backup succeeded",
+}, []string{"namespace", "repository", "restic"})
+
+func init() {
+	prometheus.MustRegister(repositorySecondsSinceLastSuccessfulBackup)
+}
+
+func (c *StashController) initRepositoryWatcher() {
+	c.repoInformer = c.stashInformerFactory.Stash().V1alpha1().Repositories().Informer()
+	c.repoQueue = queue.New("Repository", c.MaxNumRequeues, c.NumThreads, c.runRepositoryInjector)
+	// status of Repository is updated often during backup, maintenance only depends on Restic of new Repositories
+	c.repoInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			queue.Enqueue(c.repoQueue.GetQueue(), obj)
+		},
+	})
+	c.repoLister = c.stashInformerFactory.Stash().V1alpha1().Repositories().Lister()
+}
+
+func (c *StashController) runRepositoryInjector(key string) error {
+	obj, exists, err := c.repoInformer.GetIndexer().GetByKey(key)
+	if err != nil {
+		glog.Errorf("Fetching object with key %s from store failed with %v", key, err)
+		return err
+	}
+	if !exists {
+		// maintenance CronJob is deleted by garbage collector
+		glog.Warningf("Repository %s does not exist anymore\n", key)
+		return nil
+	}
+
+	repository := obj.(*api.Repository)
+	glog.Infof("Sync/Add/Update for Repository %s\n", repository.GetName())
+
+	restic, err := c.rstLister.Restics(repository.Namespace).Get(repository.Labels["restic"])
+	if kerr.IsNotFound(err) {
+		return c.EnsureMaintenanceCronJobDeleted(repository)
+	} else if err != nil {
+		return err
+	}
+	if !needsMaintenance(restic) {
+		return c.EnsureMaintenanceCronJobDeleted(repository)
+	}
+	return c.EnsureMaintenanceCronJob(restic, repository)
+}
+
+// enqueueRepositories enqueues Repositories of a Restic, so that their maintenance CronJobs follow changes of the Restic.
+func (c *StashController) enqueueRepositories(namespace, resticName string) {
+	repositories, err := c.repoLister.Repositories(namespace).List(labels.SelectorFromSet(map[string]string{
+		"restic": resticName,
+	}))
+	if err != nil {
+		log.Errorln(err)
+		return
+	}
+	for _, repository := range repositories {
+		queue.Enqueue(c.repoQueue.GetQueue(), repository)
+	}
+}
+
+// checkBackupOverdue compares last successful backup of each Repository with the schedule of its Restic,
+// updates BackupOverdue condition and exports time since last successful backup.
+func (c *StashController) checkBackupOverdue() {
+	repositories, err := c.repoLister.List(labels.Everything())
+	if err != nil {
+		log.Errorln(err)
+		return
+	}
+
+	now := time.Now()
+	repositorySecondsSinceLastSuccessfulBackup.Reset()
+	for _, repository := range repositories {
+		resticName := repository.Labels["restic"]
+		if resticName == "" {
+			continue
+		}
+		restic, err := c.rstLister.Restics(repository.Namespace).Get(resticName)
+		if kerr.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			log.Errorln(err)
+			continue
+		}
+
+		lastSuccess := repository.CreationTimestamp.Time
+		if t := lastSuccessfulBackupTime(repository); t != nil {
+			lastSuccess = t.Time
+		}
+		repositorySecondsSinceLastSuccessfulBackup.
+			WithLabelValues(repository.Namespace, repository.Name, restic.Name).
+			Set(now.Sub(lastSuccess).Seconds())
+
+		cond := backupOverdueCondition(restic, repository, lastSuccess, now)
+		if cur := repository.Status.GetCondition(api.RepositoryBackupOverdue); cur != nil &&
+			cur.Status == cond.Status && cur.Reason == cond.Reason && cur.Message == cond.Message {
+			continue
+		}
+		_, _, err = stash_util.PatchRepository(c.stashClient.StashV1alpha1(), repository, func(in *api.Repository) *api.Repository {
+			in.Status.Conditions = api.SetRepositoryCondition(in.Status.Conditions, cond)
+			return in
+		})
+		if err != nil {
+			log.Errorf("Failed to update conditions of Repository %s/%s, reason: %s\n", repository.Namespace, repository.Name, err)
+			continue
+		}
+
+		if cur := repository.Status.GetCondition(api.RepositoryBackupOverdue); cond.Status == core.ConditionTrue &&
+			(cur == nil || cur.Status != core.ConditionTrue) {
+			ref, rerr := reference.GetReference(scheme.Scheme, repository)
+			if rerr == nil {
+				c.recorder.Event(
+					ref,
+					core.EventTypeWarning,
+					eventer.EventReasonBackupOverdue,
+					cond.Message,
+				)
+			}
+		}
+	}
+}
+
+// lastSuccessfulBackupTime returns start time of the last successful backup of repository, nil if no backup succeeded.
+// Sidecars of older versions set only lastBackupTime and only if backup succeeded.
+func lastSuccessfulBackupTime(repository *api.Repository) *metav1.Time {
+	if repository.Status.LastSuccessfulBackupTime != nil {
+		return repository.Status.LastSuccessfulBackupTime
+	}
+	if repository.Status.LastFailedBackupTime == nil {
+		return repository.Status.LastBackupTime
+	}
+	return nil
+}
+
+// backupOverdueCondition returns BackupOverdue condition for repository. A backup is overdue if the run
+// scheduled after last successful backup was missed and the next scheduled run is also over.
+func backupOverdueCondition(restic *api.Restic, repository *api.Repository, lastSuccess, now time.Time) api.RepositoryCondition {
+	cond := api.RepositoryCondition{
+		Type:   api.RepositoryBackupOverdue,
+		Status: core.ConditionFalse,
+	}
+	if restic.Spec.Paused {
+		cond.Reason = BackupOverdueReasonPaused
+		cond.Message = fmt.Sprintf("Restic %s is paused", restic.Name)
+		return cond
+	}
+	schedule, err := cron.Parse(restic.Spec.CronSchedule())
+	if err != nil {
+		cond.Status = core.ConditionTrue
+		cond.Reason = BackupOverdueReasonInvalidSchedule
+		cond.Message = fmt.Sprintf("Schedule %s of Restic %s is invalid, reason: %s", restic.Spec.Schedule, restic.Name, err)
+		return cond
+	}
+
+	expected := schedule.Next(lastSuccess)
+	deadline := schedule.Next(expected)
+	if restic.Spec.Jitter != nil {
+		// scheduled backups of each sidecar are delayed by up to jitter
+		deadline = deadline.Add(restic.Spec.Jitter.Duration)
+	}
+	if now.After(deadline) {
+		cond.Status = core.ConditionTrue
+		cond.Reason = BackupOverdueReasonMissedSchedule
+		if lastSuccessfulBackupTime(repository) == nil {
+			cond.Message = fmt.Sprintf("No successful backup since Repository was created at %s, backup was expected at %s",
+				lastSuccess.UTC().Format(time.RFC3339), expected.UTC().Format(time.RFC3339))
+		} else {
+			cond.Message = fmt.Sprintf("Last successful backup at %s, backup was expected at %s",
+				lastSuccess.UTC().Format(time.RFC3339), expected.UTC().Format(time.RFC3339))
+		}
+		return cond
+	}
+	cond.Reason = BackupOverdueReasonOnSchedule
+	cond.Message = fmt.Sprintf("Next backup is expected at %s", expected.UTC().Format(time.RFC3339))
+	return cond
+}
@@ -0,0 +1,182 @@
+package controller
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	api "github.com/appscode/stash/apis/stash/v1alpha1"
+	core "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestBackupOverdueCondition(t *testing.T) {
+	created := time.Date(2018, 4, 10, 8, 30, 0, 0, time.UTC)
+	lastBackup := time.Date(2018, 4, 10, 10, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name       string
+		spec       api.ResticSpec
+		lastBackup *time.Time
+		now        time.Time
+		status     core.ConditionStatus
+		reason     string
+		message    string
+	}{
+		{
+			name:       "paused",
+			spec:       api.ResticSpec{Schedule: "0 * * * *", Paused: true},
+			lastBackup: &lastBackup,
+			now:        lastBackup.Add(24 * time.Hour),
+			status:     core.ConditionFalse,
+			reason:     BackupOverdueReasonPaused,
+		},
+		{
+			name:       "invalid schedule",
+			spec:       api.ResticSpec{Schedule: "every hour"},
+			lastBackup: &lastBackup,
+			now:        lastBackup,
+			status:     core.ConditionTrue,
+			reason:     BackupOverdueReasonInvalidSchedule,
+		},
+		{
+			name:       "next run not due",
+			spec:       api.ResticSpec{Schedule: "0 * * * *"},
+			lastBackup: &lastBackup,
+			now:        lastBackup.Add(30 * time.Minute),
+			status:     core.ConditionFalse,
+			reason:     BackupOverdueReasonOnSchedule,
+			message:    "Next backup is expected at 2018-04-10T11:00:00Z",
+		},
+		{
+			name:       "one run missed",
+			spec:       api.ResticSpec{Schedule: "0 * * * *"},
+			lastBackup: &lastBackup,
+			now:        lastBackup.Add(90 * time.Minute),
+			status:     core.ConditionFalse,
+			reason:     BackupOverdueReasonOnSchedule,
+		},
+		{
+			name:       "following run due",
+			spec:       api.ResticSpec{Schedule: "0 * * * *"},
+			lastBackup: &lastBackup,
+			now:        lastBackup.Add(2 * time.Hour),
+			status:     core.ConditionFalse,
+			reason:     BackupOverdueReasonOnSchedule,
+		},
+		{
+			name:       "overdue",
+			spec:       api.ResticSpec{Schedule: "0 * * * *"},
+			lastBackup: &lastBackup,
+			now:        lastBackup.Add(2*time.Hour + time.Minute),
+			status:     core.ConditionTrue,
+			reason:     BackupOverdueReasonMissedSchedule,
+			message:    "Last successful backup at 2018-04-10T10:00:00Z, backup was expected at 2018-04-10T11:00:00Z",
+		},
+		{
+			name:    "no backup since creation",
+			spec:    api.ResticSpec{Schedule: "0 * * * *"},
+			now:     created.Add(3 * time.Hour),
+			status:  core.ConditionTrue,
+			reason:  BackupOverdueReasonMissedSchedule,
+			message: "No successful backup since Repository was created at 2018-04-10T08:30:00Z, backup was expected at 2018-04-10T09:00:00Z",
+		},
+		{
+			name:       "delayed by jitter",
+			spec:       api.ResticSpec{Schedule: "0 * * * *", Jitter: &metav1.Duration{Duration: 5 * time.Minute}},
+			lastBackup: &lastBackup,
+			now:        lastBackup.Add(2*time.Hour + time.Minute),
+			status:     core.ConditionFalse,
+			reason:     BackupOverdueReasonOnSchedule,
+		},
+		{
+			name:       "overdue despite jitter",
+			spec:       api.ResticSpec{Schedule: "0 * * * *", Jitter: &metav1.Duration{Duration: 5 * time.Minute}},
+			lastBackup: &lastBackup,
+			now:        lastBackup.Add(2*time.Hour + 6*time.Minute),
+			status:     core.ConditionTrue,
+			reason:     BackupOverdueReasonMissedSchedule,
+		},
+		{
+			name:       "time zone",
+			spec:       api.ResticSpec{Schedule: "0 2 * * *", TimeZone: "Asia/Tokyo"},
+			lastBackup: &lastBackup,
+			now:        lastBackup.Add(time.Hour),
+			status:     core.ConditionFalse,
+			reason:     BackupOverdueReasonOnSchedule,
+			// 02:00 in Tokyo is 17:00 UTC of the previous day
+			message: "Next backup is expected at 2018-04-10T17:00:00Z",
+		},
+	}
+	for _, c := range cases {
+		restic := &api.Restic{
+			ObjectMeta: metav1.ObjectMeta{Name: "stash-demo", Namespace: "default"},
+			Spec:       c.spec,
+		}
+		repository := &api.Repository{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "deployment.stash-demo",
+				Namespace:         "default",
+				CreationTimestamp: metav1.NewTime(created),
+			},
+		}
+		lastSuccess := created
+		if c.lastBackup != nil {
+			repository.Status.LastBackupTime = &metav1.Time{Time: *c.lastBackup}
+			repository.Status.LastSuccessfulBackupTime = &metav1.Time{Time: *c.lastBackup}
+			lastSuccess = *c.lastBackup
+		}
+
+		cond := backupOverdueCondition(restic, repository, lastSuccess, c.now)
+		if cond.Type != api.RepositoryBackupOverdue {
+			t.Errorf("%s: expected type %s, got %s", c.name, api.RepositoryBackupOverdue, cond.Type)
+		}
+		if cond.Status != c.status || cond.Reason != c.reason {
+			t.Errorf("%s: expected %s/%s, got %s/%s (%s)", c.name, c.status, c.reason, cond.Status, cond.Reason, cond.Message)
+		}
+		if c.message != "" && cond.Message != c.message {
+			t.Errorf("%s: expected message %q, got %q", c.name, c.message, cond.Message)
+		}
+		if cond.Message == "" || strings.Contains(cond.Message, "\n") {
+			t.Errorf("%s: invalid message %q", c.name, cond.Message)
+		}
+	}
+}
+
+func TestLastSuccessfulBackupTime(t *testing.T) {
+	success := metav1.NewTime(time.Date(2018, 4, 10, 10, 0, 0, 0, time.UTC))
+	failure := metav1.NewTime(time.Date(2018, 4, 10, 11, 0, 0, 0, time.UTC))
+
+	cases := []struct {
+		name     string
+		status   api.RepositoryStatus
+		expected *metav1.Time
+	}{
+		{name: "no backup"},
+		{
+			name:     "succeeded",
+			status:   api.RepositoryStatus{LastBackupTime: &success, LastSuccessfulBackupTime: &success},
+			expected: &success,
+		},
+		{
+			name:     "failed after success",
+			status:   api.RepositoryStatus{LastBackupTime: &failure, LastSuccessfulBackupTime: &success, LastFailedBackupTime: &failure},
+			expected: &success,
+		},
+		{
+			name:   "failed",
+			status: api.RepositoryStatus{LastBackupTime: &failure, LastFailedBackupTime: &failure},
+		},
+		{
+			name:     "recorded by older sidecar",
+			status:   api.RepositoryStatus{LastBackupTime: &success},
+			expected: &success,
+		},
+	}
+	for _, c := range cases {
+		got := lastSuccessfulBackupTime(&api.Repository{Status: c.status})
+		if (got == nil) != (c.expected == nil) || got != nil && !got.Equal(c.expected) {
+			t.Errorf("%s: expected %v, got %v", c.name, c.expected, got)
+		}
+	}
+}
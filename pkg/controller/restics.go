@@ -126,6 +126,7 @@ func (c *StashController) runResticInjector(key string) error {
 			return err
 		}
 		c.EnsureSidecarDeleted(namespace, name)
+		c.enqueueRepositories(namespace, name)
 	} else {
 		restic := obj.(*api.Restic)
 		glog.Infof("Sync/Add/Update for Restic %s\n", restic.GetName())
@@ -135,6 +136,7 @@ func (c *StashController) runResticInjector(key string) error {
 		}
 		c.EnsureSidecar(restic)
 		c.EnsureSidecarDeleted(restic.Namespace, restic.Name)
+		c.enqueueRepositories(restic.Namespace, restic.Name)
 	}
 	return nil
 }
@@ -191,6 +193,7 @@ func (c *StashController) EnsureScaledownCronJob(restic *api.Restic) error {
 				Args: []string{
 					"scaledown",
 					"--selector=" + selector.String(),
+					"--restic-name=" + restic.Name,
 				},
 			})
 		in.Spec.JobTemplate.Spec.Template.Spec.ImagePullSecrets = restic.Spec.ImagePullSecrets
@@ -0,0 +1,223 @@
+package controller
+
+import (
+	"sort"
+	"time"
+
+	"github.com/appscode/go/log"
+	api "github.com/appscode/stash/apis/stash/v1alpha1"
+	stash_util "github.com/appscode/stash/client/clientset/versioned/typed/stash/v1alpha1/util"
+	"github.com/appscode/stash/pkg/cli"
+	"github.com/appscode/stash/pkg/eventer"
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/robfig/cron.v2"
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/reference"
+)
+
+const (
+	// Interval between checks for due backups of Restics scheduled by operator
+	backupDispatchInterval = 10 * time.Second
+)
+
+var (
+	schedulerQueuedBackups = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "stash",
+		Subsystem: "scheduler",
+		Name:      "queued_backups",
+		Help:      "Number of due backups waiting for a free slot",
+	})
+	schedulerRunningBackups = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "stash",
+		Subsystem: "scheduler",
+		Name:      "running_backups",
+		Help:      "Number of backups dispatched to sidecars and not completed yet",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(schedulerQueuedBackups, schedulerRunningBackups)
+}
+
+// backupCounter counts running backups for concurrency limits.
+type backupCounter struct {
+	total    int
+	nodes    map[string]int
+	backends map[string]int
+}
+
+func newBackupCounter() *backupCounter {
+	return &backupCounter{
+		nodes:    map[string]int{},
+		backends: map[string]int{},
+	}
+}
+
+func (n *backupCounter) add(repository *api.Repository) {
+	n.total++
+	if node := repository.Annotations[api.NodeName]; node != "" {
+		n.nodes[node]++
+	}
+	if backend := backendLocation(repository); backend != "" {
+		n.backends[backend]++
+	}
+}
+
+// backendLocation returns the location of the backend of repository, see cli.BackendProvider.
+func backendLocation(repository *api.Repository) string {
+	provider, err := cli.GetBackendProvider(&repository.Spec.Backend)
+	if err != nil {
+		return ""
+	}
+	return provider.Location(&repository.Spec.Backend)
+}
+
+// allows returns true if backup of repository can start without exceeding concurrency limits of operator.
+func (c *StashController) allows(n *backupCounter, repository *api.Repository) bool {
+	if c.MaxConcurrentBackups > 0 && n.total >= c.MaxConcurrentBackups {
+		return false
+	}
+	if node := repository.Annotations[api.NodeName]; node != "" &&
+		c.MaxConcurrentBackupsPerNode > 0 && n.nodes[node] >= c.MaxConcurrentBackupsPerNode {
+		return false
+	}
+	if backend := backendLocation(repository); backend != "" &&
+		c.MaxConcurrentBackupsPerBackend > 0 && n.backends[backend] >= c.MaxConcurrentBackupsPerBackend {
+		return false
+	}
+	return true
+}
+
+// scheduleBackups queues due backups of Restics scheduled by operator and dispatches queued backups
+// to sidecars, oldest first, as long as concurrency limits allow.
+func (c *StashController) scheduleBackups() {
+	c.scheduleBackupsAt(time.Now())
+}
+
+func (c *StashController) scheduleBackupsAt(now time.Time) {
+	repositories, err := c.repoLister.List(labels.Everything())
+	if err != nil {
+		log.Errorln(err)
+		return
+	}
+
+	running := newBackupCounter()
+	scheduled := map[string]*api.Repository{}
+	for _, repository := range repositories {
+		restic, err := c.rstLister.Restics(repository.Namespace).Get(repository.Labels["restic"])
+		if err != nil || restic.Spec.Scheduler != api.SchedulerOperator {
+			continue
+		}
+		if c.dispatchRunning(repository, now) {
+			running.add(repository)
+		}
+		if restic.Spec.Paused {
+			continue
+		}
+		schedule, err := cron.Parse(restic.Spec.CronSchedule())
+		if err != nil {
+			continue
+		}
+
+		key := repository.Namespace + "/" + repository.Name
+		scheduled[key] = repository
+		next, ok := c.nextBackups[key]
+		if !ok {
+			next = schedule.Next(lastScheduledRun(repository))
+		}
+		if now.Before(next) {
+			c.nextBackups[key] = next
+			continue
+		}
+		c.nextBackups[key] = schedule.Next(now)
+
+		if w, err := restic.Spec.ActiveBlackoutWindow(now); err == nil && w != nil {
+			ref, rerr := reference.GetReference(scheme.Scheme, restic)
+			if rerr == nil {
+				c.recorder.Eventf(
+					ref,
+					core.EventTypeNormal,
+					eventer.EventReasonSkippedBackup,
+					"Skipped scheduled backup of Repository %s during blackout window %s",
+					repository.Name, w,
+				)
+			}
+			continue
+		}
+		// missed runs of a queued backup are merged into it
+		if _, queued := c.dueBackups[key]; !queued {
+			c.dueBackups[key] = next
+		}
+	}
+	for key := range c.nextBackups {
+		if _, ok := scheduled[key]; !ok {
+			delete(c.nextBackups, key)
+			delete(c.dueBackups, key)
+		}
+	}
+
+	keys := make([]string, 0, len(c.dueBackups))
+	for key := range c.dueBackups {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return c.dueBackups[keys[i]].Before(c.dueBackups[keys[j]])
+	})
+	for _, key := range keys {
+		repository := scheduled[key]
+		if c.dispatchRunning(repository, now) || !c.allows(running, repository) {
+			continue
+		}
+		_, _, err := stash_util.PatchRepository(c.stashClient.StashV1alpha1(), repository, func(in *api.Repository) *api.Repository {
+			if in.Annotations == nil {
+				in.Annotations = map[string]string{}
+			}
+			in.Annotations[api.BackupDispatch] = now.UTC().Format(time.RFC3339)
+			return in
+		})
+		if err != nil {
+			log.Errorf("Failed to dispatch backup of Repository %s, reason: %s\n", key, err)
+			continue
+		}
+		log.Infof("Dispatched backup of Repository %s due at %s", key, c.dueBackups[key].Format(time.RFC3339))
+		running.add(repository)
+		delete(c.dueBackups, key)
+	}
+
+	schedulerQueuedBackups.Set(float64(len(c.dueBackups)))
+	schedulerRunningBackups.Set(float64(running.total))
+}
+
+// dispatchRunning returns true if the last backup dispatched to the sidecar of repository has not completed yet.
+// A backup not completed within the dispatch timeout is considered lost, eg: the sidecar was deleted.
+func (c *StashController) dispatchRunning(repository *api.Repository, now time.Time) bool {
+	v := repository.Annotations[api.BackupDispatch]
+	if v == "" || v == repository.Annotations[api.BackupDispatchCompleted] {
+		return false
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return false
+	}
+	if c.BackupDispatchTimeout > 0 && now.Sub(t) >= c.BackupDispatchTimeout {
+		return false
+	}
+	return true
+}
+
+// lastScheduledRun returns the time of the last backup dispatched to or taken by the sidecar of repository.
+func lastScheduledRun(repository *api.Repository) time.Time {
+	last := repository.CreationTimestamp.Time
+	if t, err := time.Parse(time.RFC3339, repository.Annotations[api.BackupDispatch]); err == nil && t.After(last) {
+		last = t
+	}
+	if repository.Status.LastBackupTime != nil && repository.Status.LastBackupTime.After(last) {
+		last = repository.Status.LastBackupTime.Time
+	}
+	if repository.Status.LastFailedBackupTime != nil && repository.Status.LastFailedBackupTime.After(last) {
+		last = repository.Status.LastFailedBackupTime.Time
+	}
+	return last
+}
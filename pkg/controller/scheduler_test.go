@@ -0,0 +1,329 @@
+package controller
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	api "github.com/appscode/stash/apis/stash/v1alpha1"
+	"github.com/appscode/stash/client/clientset/versioned/fake"
+	"github.com/appscode/stash/client/clientset/versioned/scheme"
+	stash_listers "github.com/appscode/stash/client/listers/stash/v1alpha1"
+	"github.com/evanphx/json-patch"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientsetscheme "k8s.io/client-go/kubernetes/scheme"
+	clientgotesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+)
+
+func init() {
+	// events are recorded for stash objects, as in the operator
+	scheme.AddToScheme(clientsetscheme.Scheme)
+}
+
+var schedulerTestCreated = time.Date(2018, 4, 10, 8, 30, 0, 0, time.UTC)
+
+func schedulerTestTime(hour, min int) time.Time {
+	return time.Date(2018, 4, 10, hour, min, 0, 0, time.UTC)
+}
+
+type schedulerTestRepository struct {
+	name       string
+	spec       api.ResticSpec
+	lastBackup *time.Time
+	dispatch   *time.Time
+	completed  bool
+	node       string
+	bucket     string
+}
+
+func (r schedulerTestRepository) objects() (*api.Restic, *api.Repository) {
+	restic := &api.Restic{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      r.name,
+			Namespace: "default",
+			SelfLink:  "/apis/stash.appscode.com/v1alpha1/namespaces/default/restics/" + r.name,
+		},
+		Spec: r.spec,
+	}
+	if restic.Spec.Schedule == "" {
+		restic.Spec.Schedule = "0 * * * *"
+	}
+	if restic.Spec.Scheduler == "" {
+		restic.Spec.Scheduler = api.SchedulerOperator
+	}
+
+	repository := &api.Repository{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "deployment." + r.name,
+			Namespace:         "default",
+			Labels:            map[string]string{"restic": r.name},
+			Annotations:       map[string]string{},
+			CreationTimestamp: metav1.NewTime(schedulerTestCreated),
+		},
+	}
+	if r.bucket != "" {
+		repository.Spec.Backend.S3 = &api.S3Spec{Endpoint: "s3.amazonaws.com", Bucket: r.bucket}
+	}
+	if r.node != "" {
+		repository.Annotations[api.NodeName] = r.node
+	}
+	if r.lastBackup != nil {
+		repository.Status.LastBackupTime = &metav1.Time{Time: *r.lastBackup}
+	}
+	if r.dispatch != nil {
+		repository.Annotations[api.BackupDispatch] = r.dispatch.Format(time.RFC3339)
+		if r.completed {
+			repository.Annotations[api.BackupDispatchCompleted] = repository.Annotations[api.BackupDispatch]
+		}
+	}
+	return restic, repository
+}
+
+// newSchedulerTestController returns a controller with listers backed by indexers. Patches of Repositories are
+// applied to the Repository indexer, as informers would do, and their names are recorded in dispatched.
+func newSchedulerTestController(config Config, repositories []schedulerTestRepository) (*StashController, cache.Indexer, *[]string) {
+	rstIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	repoIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for _, r := range repositories {
+		restic, repository := r.objects()
+		rstIndexer.Add(restic)
+		repoIndexer.Add(repository)
+	}
+
+	var dispatched []string
+	stashClient := fake.NewSimpleClientset()
+	stashClient.PrependReactor("patch", "repositories", func(action clientgotesting.Action) (bool, runtime.Object, error) {
+		patch := action.(clientgotesting.PatchAction)
+		obj, _, err := repoIndexer.GetByKey(patch.GetNamespace() + "/" + patch.GetName())
+		if err != nil {
+			return true, nil, err
+		}
+		cur, err := json.Marshal(obj)
+		if err != nil {
+			return true, nil, err
+		}
+		mod, err := jsonpatch.MergePatch(cur, patch.GetPatch())
+		if err != nil {
+			return true, nil, err
+		}
+		out := &api.Repository{}
+		if err := json.Unmarshal(mod, out); err != nil {
+			return true, nil, err
+		}
+		repoIndexer.Update(out)
+		dispatched = append(dispatched, out.Name)
+		return true, out, nil
+	})
+
+	c := &StashController{
+		Config:      config,
+		stashClient: stashClient,
+		recorder:    record.NewFakeRecorder(10),
+		rstLister:   stash_listers.NewResticLister(rstIndexer),
+		repoLister:  stash_listers.NewRepositoryLister(repoIndexer),
+		nextBackups: map[string]time.Time{},
+		dueBackups:  map[string]time.Time{},
+	}
+	return c, repoIndexer, &dispatched
+}
+
+func TestScheduleBackups(t *testing.T) {
+	at := schedulerTestTime
+	ptr := func(t time.Time) *time.Time { return &t }
+
+	cases := []struct {
+		name         string
+		config       Config
+		repositories []schedulerTestRepository
+		now          time.Time
+		dispatched   []string
+		queued       int
+		events       int
+	}{
+		{
+			name:         "due since creation",
+			repositories: []schedulerTestRepository{{name: "a"}},
+			now:          at(9, 0),
+			dispatched:   []string{"deployment.a"},
+		},
+		{
+			name:         "not due",
+			repositories: []schedulerTestRepository{{name: "a", lastBackup: ptr(at(10, 0))}},
+			now:          at(10, 59),
+		},
+		{
+			name:         "due after failed backup",
+			repositories: []schedulerTestRepository{{name: "a", dispatch: ptr(at(10, 0)), completed: true}},
+			now:          at(11, 0),
+			dispatched:   []string{"deployment.a"},
+		},
+		{
+			name:         "scheduled by sidecar",
+			repositories: []schedulerTestRepository{{name: "a", spec: api.ResticSpec{Scheduler: api.SchedulerSidecar}}},
+			now:          at(10, 5),
+		},
+		{
+			name:         "paused",
+			repositories: []schedulerTestRepository{{name: "a", spec: api.ResticSpec{Paused: true}}},
+			now:          at(10, 5),
+		},
+		{
+			name:         "invalid schedule",
+			repositories: []schedulerTestRepository{{name: "a", spec: api.ResticSpec{Schedule: "every hour"}}},
+			now:          at(10, 5),
+		},
+		{
+			name: "blackout window",
+			repositories: []schedulerTestRepository{{
+				name: "a",
+				spec: api.ResticSpec{TimeZone: "UTC", BlackoutWindows: []api.BlackoutWindow{{Start: "10:00", End: "11:00"}}},
+			}},
+			now:    at(10, 5),
+			events: 1,
+		},
+		{
+			name:         "oldest due backup first",
+			config:       Config{MaxConcurrentBackups: 1},
+			repositories: []schedulerTestRepository{{name: "a", lastBackup: ptr(at(9, 30))}, {name: "b"}},
+			now:          at(10, 5),
+			dispatched:   []string{"deployment.b"},
+			queued:       1,
+		},
+		{
+			name:   "running backup counts against limit",
+			config: Config{MaxConcurrentBackups: 1},
+			repositories: []schedulerTestRepository{
+				{name: "a", dispatch: ptr(at(10, 0))},
+				{name: "b"},
+			},
+			now:    at(10, 5),
+			queued: 1,
+		},
+		{
+			name:         "running backup is not dispatched again",
+			repositories: []schedulerTestRepository{{name: "a", dispatch: ptr(at(9, 0))}},
+			now:          at(10, 5),
+			queued:       1,
+		},
+		{
+			name:         "lost backup is dispatched again",
+			config:       Config{BackupDispatchTimeout: 30 * time.Minute},
+			repositories: []schedulerTestRepository{{name: "a", dispatch: ptr(at(9, 0))}},
+			now:          at(10, 5),
+			dispatched:   []string{"deployment.a"},
+		},
+		{
+			name:   "limit per node",
+			config: Config{MaxConcurrentBackupsPerNode: 1},
+			repositories: []schedulerTestRepository{
+				{name: "a", node: "node-1", dispatch: ptr(at(10, 0))},
+				{name: "b", node: "node-1"},
+				{name: "c", node: "node-2"},
+			},
+			now:        at(10, 5),
+			dispatched: []string{"deployment.c"},
+			queued:     1,
+		},
+		{
+			name:   "limit per backend",
+			config: Config{MaxConcurrentBackupsPerBackend: 1},
+			repositories: []schedulerTestRepository{
+				{name: "a", bucket: "stash-qa", dispatch: ptr(at(10, 0))},
+				{name: "b", bucket: "stash-qa"},
+				{name: "c", bucket: "stash-prod"},
+			},
+			now:        at(10, 5),
+			dispatched: []string{"deployment.c"},
+			queued:     1,
+		},
+	}
+	for _, c := range cases {
+		ctrl, _, dispatched := newSchedulerTestController(c.config, c.repositories)
+		ctrl.scheduleBackupsAt(c.now)
+
+		if !reflect.DeepEqual(*dispatched, c.dispatched) {
+			t.Errorf("%s: expected dispatched backups %v, got %v", c.name, c.dispatched, *dispatched)
+		}
+		if len(ctrl.dueBackups) != c.queued {
+			t.Errorf("%s: expected %d queued backups, got %v", c.name, c.queued, ctrl.dueBackups)
+		}
+		if events := len(ctrl.recorder.(*record.FakeRecorder).Events); events != c.events {
+			t.Errorf("%s: expected %d events, got %d", c.name, c.events, events)
+		}
+	}
+}
+
+func TestScheduleBackupsQueue(t *testing.T) {
+	at := schedulerTestTime
+	lastBackup := at(9, 30)
+	ctrl, repoIndexer, dispatched := newSchedulerTestController(Config{MaxConcurrentBackups: 1}, []schedulerTestRepository{
+		{name: "a", lastBackup: &lastBackup},
+		{name: "b"},
+	})
+	complete := func(name string) {
+		obj, _, err := repoIndexer.GetByKey("default/" + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		repository := obj.(*api.Repository).DeepCopy()
+		repository.Annotations[api.BackupDispatchCompleted] = repository.Annotations[api.BackupDispatch]
+		repoIndexer.Update(repository)
+	}
+
+	steps := []struct {
+		name       string
+		now        time.Time
+		complete   string
+		dispatched []string
+		queued     int
+	}{
+		// missed runs of b at 09:00 and 10:00 are merged into one backup
+		{name: "both due", now: at(10, 5), dispatched: []string{"deployment.b"}, queued: 1},
+		{name: "b running", now: at(10, 6), dispatched: []string{"deployment.b"}, queued: 1},
+		{name: "b completed", now: at(10, 7), complete: "deployment.b", dispatched: []string{"deployment.b", "deployment.a"}},
+		{name: "a running", now: at(10, 30), dispatched: []string{"deployment.b", "deployment.a"}},
+		{name: "a completed", now: at(10, 59), complete: "deployment.a", dispatched: []string{"deployment.b", "deployment.a"}},
+	}
+	for _, s := range steps {
+		if s.complete != "" {
+			complete(s.complete)
+		}
+		ctrl.scheduleBackupsAt(s.now)
+		if !reflect.DeepEqual(*dispatched, s.dispatched) {
+			t.Errorf("%s: expected dispatched backups %v, got %v", s.name, s.dispatched, *dispatched)
+		}
+		if len(ctrl.dueBackups) != s.queued {
+			t.Errorf("%s: expected %d queued backups, got %v", s.name, s.queued, ctrl.dueBackups)
+		}
+	}
+}
+
+func TestLastScheduledRun(t *testing.T) {
+	at := schedulerTestTime
+	ptr := func(t time.Time) *time.Time { return &t }
+	cases := []struct {
+		name       string
+		repository schedulerTestRepository
+		expected   time.Time
+	}{
+		{name: "created", repository: schedulerTestRepository{name: "a"}, expected: schedulerTestCreated},
+		{name: "dispatched", repository: schedulerTestRepository{name: "a", dispatch: ptr(at(10, 0)), lastBackup: ptr(at(9, 0))}, expected: at(10, 0)},
+		{name: "backup by sidecar", repository: schedulerTestRepository{name: "a", dispatch: ptr(at(9, 0)), lastBackup: ptr(at(10, 0))}, expected: at(10, 0)},
+	}
+	for _, c := range cases {
+		_, repository := c.repository.objects()
+		if got := lastScheduledRun(repository); !got.Equal(c.expected) {
+			t.Errorf("%s: expected %s, got %s", c.name, c.expected, got)
+		}
+	}
+
+	_, repository := schedulerTestRepository{name: "a", lastBackup: ptr(at(9, 0))}.objects()
+	repository.Status.LastFailedBackupTime = &metav1.Time{Time: at(10, 0)}
+	if got := lastScheduledRun(repository); !got.Equal(at(10, 0)) {
+		t.Errorf("failed backup: expected %s, got %s", at(10, 0), got)
+	}
+}
@@ -34,6 +34,10 @@ func (c *StashController) ensureWorkloadSidecar(w *workload.Workload, oldRestic,
 		if err != nil {
 			return err
 		}
+		err = c.ensurePodsRBAC(ref, GetPodsRoleName(c.getSidecarRoleBindingName(w.Name)), sa, sidecarPodsRules(newRestic))
+		if err != nil {
+			return err
+		}
 	}
 
 	if newRestic.Spec.Backend.StorageSecretName == "" {
@@ -108,6 +112,13 @@ func (c *StashController) ensureWorkloadSidecarDeleted(w *workload.Workload, res
 		if err != nil {
 			return err
 		}
+		err = c.ensurePodsRBACDeleted(metav1.ObjectMeta{
+			Name:      GetPodsRoleName(c.getSidecarRoleBindingName(w.Name)),
+			Namespace: w.Namespace,
+		})
+		if err != nil {
+			return err
+		}
 	}
 
 	if w.Spec.Template.Annotations != nil {
@@ -124,7 +135,7 @@ func (c *StashController) ensureWorkloadSidecarDeleted(w *workload.Workload, res
 	w.Spec.Template.Spec.Volumes = util.EnsureVolumeDeleted(w.Spec.Template.Spec.Volumes, util.ScratchDirVolumeName)
 	w.Spec.Template.Spec.Volumes = util.EnsureVolumeDeleted(w.Spec.Template.Spec.Volumes, util.PodinfoVolumeName)
 
-	if restic.Spec.Backend.Local != nil {
+	if util.BackendRequiresVolume(restic.Spec.Backend) {
 		w.Spec.Template.Spec.Volumes = util.EnsureVolumeDeleted(w.Spec.Template.Spec.Volumes, util.LocalVolumeName)
 	}
 	if w.Annotations != nil {
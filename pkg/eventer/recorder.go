@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/appscode/go/log"
+	"github.com/appscode/stash/pkg/cli"
 	core "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -25,6 +26,7 @@ const (
 	EventReasonFailedToRecover               = "FailedRecovery"
 	EventReasonSuccessfulCheck               = "SuccessfulCheck"
 	EventReasonFailedToCheck                 = "FailedCheck"
+	EventReasonSuccessfulRetention           = "SuccessfulRetention"
 	EventReasonFailedToRetention             = "FailedRetention"
 	EventReasonFailedToUpdate                = "FailedUpdateBackup"
 	EventReasonFailedCronJob                 = "FailedCronJob"
@@ -32,8 +34,44 @@ const (
 	EventReasonJobCreated                    = "RecoveryJobCreated"
 	EventReasonCheckJobCreated               = "CheckJobCreated"
 	EventReasonFailedSetup                   = "SetupFailed"
+	EventReasonSuccessfulHook                = "SuccessfulHook"
+	EventReasonFailedHook                    = "FailedHook"
+	EventReasonSuccessfulTriggeredBackup     = "SuccessfulTriggeredBackup"
+	EventReasonFailedTriggeredBackup         = "FailedTriggeredBackup"
+	EventReasonSkippedTriggeredBackup        = "SkippedTriggeredBackup"
+	EventReasonBackupOverdue                 = "BackupOverdue"
+	EventReasonSkippedBackup                 = "SkippedBackup"
+	EventReasonRepositorySizeExceeded        = "RepositorySizeExceeded"
+	EventReasonRepositoryUnlocked            = "RepositoryUnlocked"
+
+	// Reasons for restic failures, see ReasonForError
+	EventReasonRepositoryLocked        = "RepositoryLocked"
+	EventReasonWrongRepositoryPassword = "WrongRepositoryPassword"
+	EventReasonRepositoryNotFound      = "RepositoryNotFound"
+	EventReasonBackendUnreachable      = "BackendUnreachable"
+	EventReasonBackendPermissionDenied = "BackendPermissionDenied"
+	EventReasonBackendOutOfSpace       = "BackendOutOfSpace"
+	EventReasonResticTimeout           = "ResticTimeout"
 )
 
+var errorReasons = map[cli.ErrorType]string{
+	cli.ErrorRepositoryLocked:   EventReasonRepositoryLocked,
+	cli.ErrorWrongPassword:      EventReasonWrongRepositoryPassword,
+	cli.ErrorRepositoryNotFound: EventReasonRepositoryNotFound,
+	cli.ErrorBackendUnreachable: EventReasonBackendUnreachable,
+	cli.ErrorPermissionDenied:   EventReasonBackendPermissionDenied,
+	cli.ErrorOutOfSpace:         EventReasonBackendOutOfSpace,
+	cli.ErrorTimeout:            EventReasonResticTimeout,
+}
+
+// ReasonForError returns the event reason for a classified restic error, fallback otherwise.
+func ReasonForError(err error, fallback string) string {
+	if r, ok := errorReasons[cli.ErrorTypeOf(err)]; ok {
+		return r
+	}
+	return fallback
+}
+
 func NewEventRecorder(client kubernetes.Interface, component string) record.EventRecorder {
 	// Event Broadcaster
 	broadcaster := record.NewBroadcaster()
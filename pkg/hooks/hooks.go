@@ -0,0 +1,189 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	api "github.com/appscode/stash/apis/stash/v1alpha1"
+	"github.com/pkg/errors"
+	core "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+	utilexec "k8s.io/client-go/util/exec"
+)
+
+const (
+	DefaultTimeout = 30 * time.Second
+
+	// Maximum length of hook output kept in events and status
+	maxOutputLength = 512
+)
+
+// Executor runs hooks in a pod.
+type Executor struct {
+	KubeClient kubernetes.Interface
+	Config     *rest.Config
+}
+
+func New(kubeClient kubernetes.Interface, config *rest.Config) *Executor {
+	return &Executor{
+		KubeClient: kubeClient,
+		Config:     config,
+	}
+}
+
+// Execute runs hook in pod namespace/podName and returns its output.
+func (e *Executor) Execute(hook api.Hook, namespace, podName string) (string, error) {
+	timeout := hookTimeout(hook.TimeoutSeconds)
+	switch {
+	case hook.Exec != nil:
+		return e.exec(hook.Exec, namespace, podName, timeout)
+	case hook.HTTP != nil:
+		return httpCall(hook.HTTP, timeout)
+	}
+	return "", fmt.Errorf("hook %s has no action", hook.Name)
+}
+
+// ExecuteRecoveryHook runs hook locally or in a pod of namespace and returns its output.
+func (e *Executor) ExecuteRecoveryHook(hook api.RecoveryHook, namespace string) (string, error) {
+	timeout := hookTimeout(hook.TimeoutSeconds)
+	switch {
+	case len(hook.Command) > 0:
+		return runCommand(hook.Command, timeout)
+	case hook.Exec != nil:
+		return e.exec(&hook.Exec.ExecHook, namespace, hook.Exec.Pod, timeout)
+	}
+	return "", fmt.Errorf("hook %s has no action", hook.Name)
+}
+
+// ExitCode returns exit code of the command of a failed hook, -1 if the command did not exit normally.
+func ExitCode(err error) int32 {
+	switch e := err.(type) {
+	case nil:
+		return 0
+	case utilexec.ExitError:
+		return int32(e.ExitStatus())
+	case *exec.ExitError:
+		if status, ok := e.Sys().(syscall.WaitStatus); ok && status.Exited() {
+			return int32(status.ExitStatus())
+		}
+	}
+	return -1
+}
+
+func hookTimeout(seconds int32) time.Duration {
+	if seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return DefaultTimeout
+}
+
+func runCommand(command []string, timeout time.Duration) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, command[0], command[1:]...).CombinedOutput()
+	if ctx.Err() == context.DeadlineExceeded {
+		return Truncate(string(out)), fmt.Errorf("timed out after %s", timeout)
+	}
+	return Truncate(string(out)), err
+}
+
+func (e *Executor) exec(hook *api.ExecHook, namespace, podName string, timeout time.Duration) (string, error) {
+	req := e.KubeClient.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Name(podName).
+		Namespace(namespace).
+		SubResource("exec")
+	req.VersionedParams(&core.PodExecOptions{
+		Container: hook.Container,
+		Command:   hook.Command,
+		Stdout:    true,
+		Stderr:    true,
+	}, scheme.ParameterCodec)
+
+	executor, err := remotecommand.NewSPDYExecutor(e.Config, "POST", req.URL())
+	if err != nil {
+		return "", errors.Wrap(err, "failed to init executor")
+	}
+
+	var stdout, stderr bytes.Buffer
+	done := make(chan error, 1)
+	go func() {
+		done <- executor.Stream(remotecommand.StreamOptions{
+			Stdout: &stdout,
+			Stderr: &stderr,
+		})
+	}()
+
+	select {
+	case err = <-done:
+	case <-time.After(timeout):
+		// stream can't be canceled, it is left to finish in background
+		return "", fmt.Errorf("timed out after %s", timeout)
+	}
+	return Truncate(stdout.String() + stderr.String()), err
+}
+
+func httpCall(hook *api.HTTPHook, timeout time.Duration) (string, error) {
+	scheme := strings.ToLower(string(hook.Scheme))
+	if scheme == "" {
+		scheme = "http"
+	}
+	host := hook.Host
+	if host == "" {
+		host = "localhost"
+	}
+	method := hook.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	u := url.URL{
+		Scheme: scheme,
+		Host:   net.JoinHostPort(host, strconv.Itoa(int(hook.Port))),
+		Path:   hook.Path,
+	}
+
+	req, err := http.NewRequest(method, u.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	for _, h := range hook.HTTPHeaders {
+		req.Header.Add(h.Name, h.Value)
+	}
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	output := Truncate(string(body))
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusBadRequest {
+		return output, fmt.Errorf("%s %s returned %s", method, u.String(), resp.Status)
+	}
+	return output, nil
+}
+
+// Truncate keeps the last part of long hook output, so that it fits in events and status.
+func Truncate(output string) string {
+	output = strings.TrimSpace(output)
+	if len(output) > maxOutputLength {
+		output = "..." + output[len(output)-maxOutputLength:]
+	}
+	return output
+}
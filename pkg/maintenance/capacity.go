@@ -0,0 +1,124 @@
+package maintenance
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	api "github.com/appscode/stash/apis/stash/v1alpha1"
+	"github.com/appscode/stash/pkg/cli"
+	"github.com/appscode/stash/pkg/util"
+)
+
+// maxSizeExceededError is returned when snapshots protected from removal alone exceed maxRepositorySize of Restic.
+type maxSizeExceededError struct {
+	size  uint64
+	limit uint64
+}
+
+func (e *maxSizeExceededError) Error() string {
+	return fmt.Sprintf("protected snapshots need %s, more than maxRepositorySize %s",
+		util.FormatBytes(int64(e.size)), util.FormatBytes(int64(e.limit)))
+}
+
+// fitIntoMaxSize removes the oldest snapshots that are not protected until the repository fits into maxRepositorySize
+// of restic after prune. Snapshots kept by retention policies and the latest snapshot of each host and paths are protected.
+// Snapshots in excluded, ie: that dry run policies would remove, are not counted. If dryRun is true, the snapshots
+// that would be removed are returned without removing them. If protected snapshots alone exceed the limit,
+// nothing is removed and a *maxSizeExceededError is returned.
+func (c *Controller) fitIntoMaxSize(resticCLI *cli.ResticWrapper, restic *api.Restic, repository *api.Repository, protected, excluded map[string]bool, dryRun bool) ([]cli.Snapshot, error) {
+	limit := uint64(restic.Spec.MaxRepositorySize.Value())
+
+	all, err := resticCLI.ListSnapshots(nil)
+	if err != nil {
+		return nil, err
+	}
+	var snapshots []cli.Snapshot
+	allIDs := make([]string, 0, len(all))
+	for _, snapshot := range all {
+		allIDs = append(allIDs, snapshot.ID)
+		if !excluded[snapshot.ID] {
+			snapshots = append(snapshots, snapshot)
+		}
+	}
+	// size of the repository is computed by one command for all remaining snapshots
+	short := cli.ShortIDs(allIDs)
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].Time.Before(snapshots[j].Time)
+	})
+	latest := map[string]string{}
+	for _, snapshot := range snapshots {
+		latest[snapshot.Hostname+":"+strings.Join(snapshot.Paths, ",")] = snapshot.ID
+	}
+	for _, id := range latest {
+		protected[id] = true
+	}
+
+	// candidates are removed oldest first, size of the repository after removing k of them is not increasing in k
+	var candidates []cli.Snapshot
+	for _, snapshot := range snapshots {
+		if !protected[snapshot.ID] {
+			candidates = append(candidates, snapshot)
+		}
+	}
+	n, err := removalCount(len(candidates), limit, func(k int) (uint64, error) {
+		removed := map[string]bool{}
+		for _, snapshot := range candidates[:k] {
+			removed[snapshot.ID] = true
+		}
+		var ids []string
+		for _, snapshot := range snapshots {
+			if !removed[snapshot.ID] {
+				ids = append(ids, short[snapshot.ID])
+			}
+		}
+		return resticCLI.RawDataSize(ids)
+	})
+	if err != nil || n == 0 {
+		return nil, err
+	}
+
+	removed := candidates[:n]
+	if dryRun {
+		return removed, nil
+	}
+	ids := make([]string, 0, n)
+	for _, snapshot := range removed {
+		ids = append(ids, snapshot.ID)
+	}
+	err = c.waitForLock(resticCLI, restic, repository, func() error {
+		return resticCLI.ForgetSnapshots(ids)
+	})
+	return removed, err
+}
+
+// removalCount returns the smallest number of the n candidates that must be removed to fit the repository into limit.
+// sizeAfter returns the size of the repository after removing the first k candidates and must not increase in k.
+// If the repository does not fit even after removing all candidates, a *maxSizeExceededError is returned.
+func removalCount(n int, limit uint64, sizeAfter func(k int) (uint64, error)) (int, error) {
+	size, err := sizeAfter(0)
+	if err != nil || size <= limit {
+		return 0, err
+	}
+	minSize, err := sizeAfter(n)
+	if err != nil {
+		return 0, err
+	}
+	if minSize > limit {
+		return 0, &maxSizeExceededError{size: minSize, limit: limit}
+	}
+	lo, hi := 1, n
+	for lo < hi {
+		mid := (lo + hi) / 2
+		s, err := sizeAfter(mid)
+		if err != nil {
+			return 0, err
+		}
+		if s <= limit {
+			hi = mid
+		} else {
+			lo = mid + 1
+		}
+	}
+	return lo, nil
+}
@@ -0,0 +1,70 @@
+package maintenance
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRemovalCount(t *testing.T) {
+	// sizes of the repository after removing 0 to 5 candidates
+	sizes := []uint64{100, 90, 70, 70, 40, 30}
+	cases := []struct {
+		name     string
+		limit    uint64
+		expected int
+		exceeded bool
+	}{
+		{name: "fits", limit: 100, expected: 0},
+		{name: "remove one", limit: 95, expected: 1},
+		{name: "remove two", limit: 89, expected: 2},
+		{name: "same size after removing more", limit: 70, expected: 2},
+		{name: "remove four", limit: 69, expected: 4},
+		{name: "remove all", limit: 30, expected: 5},
+		{name: "protected snapshots exceed limit", limit: 29, exceeded: true},
+	}
+	for _, c := range cases {
+		calls := 0
+		n, err := removalCount(len(sizes)-1, c.limit, func(k int) (uint64, error) {
+			calls++
+			return sizes[k], nil
+		})
+		if c.exceeded {
+			e, ok := err.(*maxSizeExceededError)
+			if !ok {
+				t.Errorf("%s: expected max size exceeded error, got %v", c.name, err)
+			} else if e.size != sizes[len(sizes)-1] || e.limit != c.limit {
+				t.Errorf("%s: expected size %d and limit %d, got %+v", c.name, sizes[len(sizes)-1], c.limit, e)
+			}
+			if n != 0 {
+				t.Errorf("%s: expected no snapshot to be removed, got %d", c.name, n)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %s", c.name, err)
+			continue
+		}
+		if n != c.expected {
+			t.Errorf("%s: expected to remove %d snapshots, got %d", c.name, c.expected, n)
+		}
+		// 2 calls for the bounds and a binary search among 5 candidates
+		if calls > 5 {
+			t.Errorf("%s: expected at most 5 size calls, got %d", c.name, calls)
+		}
+	}
+}
+
+func TestRemovalCountError(t *testing.T) {
+	statsErr := errors.New("stats failed")
+	for _, failAt := range []int{0, 4, 2} {
+		_, err := removalCount(4, 10, func(k int) (uint64, error) {
+			if k == failAt {
+				return 0, statsErr
+			}
+			return uint64(20 - 5*k), nil
+		})
+		if err != statsErr {
+			t.Errorf("stats failing after removing %d: expected %v, got %v", failAt, statsErr, err)
+		}
+	}
+}
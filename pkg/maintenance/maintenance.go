@@ -0,0 +1,321 @@
+package maintenance
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/appscode/go/log"
+	api "github.com/appscode/stash/apis/stash/v1alpha1"
+	cs "github.com/appscode/stash/client/clientset/versioned"
+	stash_util "github.com/appscode/stash/client/clientset/versioned/typed/stash/v1alpha1/util"
+	"github.com/appscode/stash/pkg/cli"
+	"github.com/appscode/stash/pkg/eventer"
+	"github.com/appscode/stash/pkg/util"
+	core "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/reference"
+)
+
+const (
+	MaintenanceEventComponent = "stash-maintenance"
+
+	// Interval between attempts while the repository is locked by a backup
+	LockRetryInterval = time.Minute
+	// Maximum duration to wait for the repository lock
+	LockWaitTimeout = time.Hour
+)
+
+type Options struct {
+	Namespace      string
+	RepositoryName string
+	ScratchDir     string
+}
+
+type Controller struct {
+	k8sClient   kubernetes.Interface
+	stashClient cs.Interface
+	opt         Options
+}
+
+func New(k8sClient kubernetes.Interface, stashClient cs.Interface, opt Options) *Controller {
+	return &Controller{
+		k8sClient:   k8sClient,
+		stashClient: stashClient,
+		opt:         opt,
+	}
+}
+
+// Run applies retention policies and maxRepositorySize of the Restic of the Repository and prunes the repository
+// if a policy requests it or snapshots were removed to fit into maxRepositorySize.
+// Result is recorded in the status of the Repository and as event.
+func (c *Controller) Run() (err error) {
+	repository, err := c.stashClient.StashV1alpha1().Repositories(c.opt.Namespace).Get(c.opt.RepositoryName, metav1.GetOptions{})
+	if err != nil {
+		return
+	}
+	restic, err := c.stashClient.StashV1alpha1().Restics(c.opt.Namespace).Get(repository.Labels["restic"], metav1.GetOptions{})
+	if err != nil {
+		return
+	}
+
+	startTime := metav1.Now()
+	status := &api.RepositoryMaintenance{
+		StartTime: &startTime,
+	}
+	defer func() {
+		completionTime := metav1.Now()
+		status.CompletionTime = &completionTime
+		if err != nil {
+			status.Error = err.Error()
+		}
+		_, _, perr := stash_util.PatchRepository(c.stashClient.StashV1alpha1(), repository, func(in *api.Repository) *api.Repository {
+			in.Status.LastMaintenance = status
+			return in
+		})
+		if perr != nil {
+			log.Errorf("Failed to update status of Repository %s/%s, reason: %s\n", repository.Namespace, repository.Name, perr)
+		}
+
+		if err != nil {
+			reason := eventer.ReasonForError(err, eventer.EventReasonFailedToRetention)
+			if _, ok := err.(*maxSizeExceededError); ok {
+				reason = eventer.EventReasonRepositorySizeExceeded
+			}
+			c.recordEvent(
+				repository,
+				core.EventTypeWarning,
+				reason,
+				fmt.Sprintf("Failed to apply retention policies, reason: %s", err),
+			)
+			return
+		}
+		var ids, sizeIDs []string
+		for _, snapshot := range status.RemovedSnapshots {
+			if snapshot.DryRun {
+				continue
+			}
+			if snapshot.ExceededMaxSize {
+				sizeIDs = append(sizeIDs, snapshot.ID)
+			} else {
+				ids = append(ids, snapshot.ID)
+			}
+		}
+		msg := fmt.Sprintf("Removed %d snapshots", len(ids))
+		if len(ids) > 0 {
+			msg = fmt.Sprintf("%s: %s", msg, strings.Join(ids, ", "))
+		}
+		if len(sizeIDs) > 0 {
+			msg = fmt.Sprintf("%s, removed %d snapshots to fit into maxRepositorySize %s: %s",
+				msg, len(sizeIDs), restic.Spec.MaxRepositorySize.String(), strings.Join(sizeIDs, ", "))
+		}
+		if status.Pruned {
+			msg += ", pruned unused data"
+		}
+		c.recordEvent(repository, core.EventTypeNormal, eventer.EventReasonSuccessfulRetention, msg)
+	}()
+
+	resticCLI, err := c.setupRestic(repository)
+	if err != nil {
+		return
+	}
+
+	prune, dryRun := false, false
+	kept, dryRunRemoved := map[string]bool{}, map[string]bool{}
+	for _, fg := range restic.Spec.FileGroups {
+		policy := retentionPolicy(restic, fg)
+		if policy == nil {
+			continue
+		}
+		var groups []cli.ForgetGroup
+		err = c.waitForLock(resticCLI, restic, repository, func() (err error) {
+			groups, err = resticCLI.Forget(fg.Path, *policy)
+			return
+		})
+		if err != nil {
+			return
+		}
+		if policy.DryRun {
+			dryRun = true
+		}
+		for _, g := range groups {
+			for _, snapshot := range g.Keep {
+				kept[snapshot.ID] = true
+			}
+			for _, snapshot := range g.Remove {
+				status.RemovedSnapshots = append(status.RemovedSnapshots, api.RemovedSnapshot{
+					ID:                  snapshot.ID,
+					Time:                metav1.NewTime(snapshot.Time),
+					Hostname:            snapshot.Hostname,
+					Paths:               snapshot.Paths,
+					RetentionPolicyName: policy.Name,
+					DryRun:              policy.DryRun,
+				})
+				if policy.DryRun {
+					dryRunRemoved[snapshot.ID] = true
+				} else if policy.Prune {
+					prune = true
+				}
+			}
+		}
+	}
+
+	// maxRepositorySize is applied as dry run if a retention policy is a dry run
+	var sizeErr error
+	if restic.Spec.MaxRepositorySize != nil {
+		removed, ferr := c.fitIntoMaxSize(resticCLI, restic, repository, kept, dryRunRemoved, dryRun)
+		if _, ok := ferr.(*maxSizeExceededError); ok {
+			// snapshots removed by retention policies are still pruned
+			sizeErr = ferr
+		} else if ferr != nil {
+			err = ferr
+			return
+		}
+		for _, snapshot := range removed {
+			status.RemovedSnapshots = append(status.RemovedSnapshots, api.RemovedSnapshot{
+				ID:              snapshot.ID,
+				Time:            metav1.NewTime(snapshot.Time),
+				Hostname:        snapshot.Hostname,
+				Paths:           snapshot.Paths,
+				DryRun:          dryRun,
+				ExceededMaxSize: true,
+			})
+			// space is only reclaimed by prune
+			if !dryRun {
+				prune = true
+			}
+		}
+	}
+
+	if prune {
+		if err = c.waitForLock(resticCLI, restic, repository, resticCLI.Prune); err != nil {
+			return
+		}
+		status.Pruned = true
+	}
+	err = sizeErr
+	return
+}
+
+// setupRestic configures restic to access the repository of the Repository from the maintenance job.
+// The volume of a local backend is mounted by the job with the subPath of the Repository, which includes
+// the prefix of the workload.
+func (c *Controller) setupRestic(repository *api.Repository) (*cli.ResticWrapper, error) {
+	backend := repository.Spec.Backend.DeepCopy()
+	provider, err := cli.GetBackendProvider(backend)
+	if err != nil {
+		return nil, err
+	}
+	provider.TrimPrefix(backend, "")
+	return c.newResticCLI(*backend, "")
+}
+
+// setupSidecarRestic configures restic to access the repository of the Repository from the sidecar of the workload.
+// The backend is resolved as backup does, ie: the backend of restic with the prefix of the workload, since the volume
+// of a local backend is mounted by the sidecar with the subPath of restic.
+func (c *Controller) setupSidecarRestic(restic *api.Restic, repository *api.Repository) (*cli.ResticWrapper, error) {
+	autoPrefix, err := workloadPrefix(restic, repository)
+	if err != nil {
+		return nil, err
+	}
+	return c.newResticCLI(restic.Spec.Backend, autoPrefix)
+}
+
+func (c *Controller) newResticCLI(backend api.Backend, autoPrefix string) (*cli.ResticWrapper, error) {
+	secret, err := c.k8sClient.CoreV1().Secrets(c.opt.Namespace).Get(backend.StorageSecretName, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	resticCLI := cli.New(c.opt.ScratchDir, false, "")
+	if _, err = resticCLI.SetupEnv(backend, secret, autoPrefix); err != nil {
+		return nil, err
+	}
+	return resticCLI, nil
+}
+
+// workloadPrefix returns the prefix of the workload that backup appends to the backend of restic for the repository
+// of the Repository, eg: deployment/stash-demo.
+func workloadPrefix(restic *api.Restic, repository *api.Repository) (string, error) {
+	backend := repository.Spec.Backend.DeepCopy()
+	provider, err := cli.GetBackendProvider(backend)
+	if err != nil {
+		return "", err
+	}
+	if p, err := cli.GetBackendProvider(&restic.Spec.Backend); err != nil || p.Name() != provider.Name() {
+		return "", fmt.Errorf("backend of Repository %s is not the %s backend of Restic %s", repository.Name, provider.Name(), restic.Name)
+	}
+	provider.TrimPrefix(backend, "")
+	prefix, err := provider.Prefix(backend, "")
+	if err != nil {
+		return "", err
+	}
+	resticPrefix, err := provider.Prefix(&restic.Spec.Backend, "")
+	if err != nil {
+		return "", err
+	}
+	if resticPrefix == "" || resticPrefix == "/" {
+		return strings.TrimPrefix(prefix, "/"), nil
+	}
+	if !strings.HasPrefix(prefix, resticPrefix+"/") {
+		return "", fmt.Errorf("prefix %s of Repository %s is not within prefix %s of Restic %s", prefix, repository.Name, resticPrefix, restic.Name)
+	}
+	return strings.TrimPrefix(prefix, resticPrefix+"/"), nil
+}
+
+// repositoryWorkload returns the workload that backs up to the repository of the Repository.
+func repositoryWorkload(repository *api.Repository) api.LocalTypedReference {
+	return api.LocalTypedReference{
+		Kind: repository.Labels["workload-kind"],
+		Name: repository.Labels["workload-name"],
+	}
+}
+
+// retentionPolicy returns the retention policy of fg, nil if fg has none.
+func retentionPolicy(restic *api.Restic, fg api.FileGroup) *api.RetentionPolicy {
+	if fg.RetentionPolicyName == "" {
+		return nil
+	}
+	for i, policy := range restic.Spec.RetentionPolicies {
+		if policy.Name == fg.RetentionPolicyName {
+			return &restic.Spec.RetentionPolicies[i]
+		}
+	}
+	return nil
+}
+
+// waitForLock runs f until it does not fail because the repository is locked, eg: by a running backup.
+// Stale locks, eg: of a killed backup, are removed instead of waiting.
+func (c *Controller) waitForLock(resticCLI *cli.ResticWrapper, restic *api.Restic, repository *api.Repository, f func() error) error {
+	deadline := time.Now().Add(LockWaitTimeout)
+	for {
+		err := f()
+		if cli.ErrorTypeOf(err) != cli.ErrorRepositoryLocked || time.Now().After(deadline) {
+			return err
+		}
+		locks, uerr := util.RemoveStaleLocks(c.k8sClient, resticCLI, c.opt.Namespace, repositoryWorkload(repository), restic.Spec.ExecutionPolicy)
+		if uerr != nil {
+			log.Errorf("Failed to remove stale locks of Repository %s/%s, reason: %s\n", repository.Namespace, repository.Name, uerr)
+		} else if len(locks) > 0 {
+			c.recordEvent(repository, core.EventTypeNormal, eventer.EventReasonRepositoryUnlocked, util.StaleLocksMessage(locks))
+			continue
+		}
+		log.Infof("Repository is locked, retrying in %s", LockRetryInterval)
+		time.Sleep(LockRetryInterval)
+	}
+}
+
+func (c *Controller) recordEvent(repository *api.Repository, eventType, reason, msg string) {
+	ref, rerr := reference.GetReference(scheme.Scheme, repository)
+	if rerr == nil {
+		eventer.CreateEventWithLog(
+			c.k8sClient,
+			MaintenanceEventComponent,
+			ref,
+			eventType,
+			reason,
+			msg,
+		)
+	}
+}
@@ -0,0 +1,85 @@
+package maintenance
+
+import (
+	"testing"
+
+	api "github.com/appscode/stash/apis/stash/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestWorkloadPrefix(t *testing.T) {
+	cases := []struct {
+		name       string
+		restic     api.Backend
+		repository api.Backend
+		expected   string
+		err        bool
+	}{
+		{
+			name:       "local",
+			restic:     api.Backend{Local: &api.LocalSpec{MountPath: "/safe/data"}},
+			repository: api.Backend{Local: &api.LocalSpec{MountPath: "/safe/data", SubPath: "deployment/stash-demo"}},
+			expected:   "deployment/stash-demo",
+		},
+		{
+			name:       "local with subPath",
+			restic:     api.Backend{Local: &api.LocalSpec{MountPath: "/safe/data", SubPath: "backups"}},
+			repository: api.Backend{Local: &api.LocalSpec{MountPath: "/safe/data", SubPath: "backups/statefulset/stash-demo-0"}},
+			expected:   "statefulset/stash-demo-0",
+		},
+		{
+			name:       "s3",
+			restic:     api.Backend{S3: &api.S3Spec{Bucket: "stash-qa", Prefix: "demo"}},
+			repository: api.Backend{S3: &api.S3Spec{Bucket: "stash-qa", Prefix: "stash-qa/demo/deployment/stash-demo"}},
+			expected:   "deployment/stash-demo",
+		},
+		{
+			name:       "s3 without prefix",
+			restic:     api.Backend{S3: &api.S3Spec{Bucket: "stash-qa"}},
+			repository: api.Backend{S3: &api.S3Spec{Bucket: "stash-qa", Prefix: "stash-qa/deployment/stash-demo"}},
+			expected:   "deployment/stash-demo",
+		},
+		{
+			name:       "rest",
+			restic:     api.Backend{Rest: &api.RestServerSpec{URL: "http://rest-server:8000/"}},
+			repository: api.Backend{Rest: &api.RestServerSpec{URL: "http://rest-server:8000/daemonset/stash-demo/node-1"}},
+			expected:   "daemonset/stash-demo/node-1",
+		},
+		{
+			name:       "other prefix",
+			restic:     api.Backend{Local: &api.LocalSpec{MountPath: "/safe/data", SubPath: "backups"}},
+			repository: api.Backend{Local: &api.LocalSpec{MountPath: "/safe/data", SubPath: "backups-old/deployment/stash-demo"}},
+			err:        true,
+		},
+		{
+			name:       "other backend",
+			restic:     api.Backend{GCS: &api.GCSSpec{Bucket: "stash-qa"}},
+			repository: api.Backend{S3: &api.S3Spec{Bucket: "stash-qa", Prefix: "stash-qa/deployment/stash-demo"}},
+			err:        true,
+		},
+	}
+	for _, c := range cases {
+		restic := &api.Restic{
+			ObjectMeta: metav1.ObjectMeta{Name: "stash-demo"},
+			Spec:       api.ResticSpec{Backend: c.restic},
+		}
+		repository := &api.Repository{
+			ObjectMeta: metav1.ObjectMeta{Name: "deployment.stash-demo"},
+			Spec:       api.RepositorySpec{Backend: c.repository},
+		}
+		prefix, err := workloadPrefix(restic, repository)
+		if c.err {
+			if err == nil {
+				t.Errorf("%s: expected error, got prefix %q", c.name, prefix)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %s", c.name, err)
+			continue
+		}
+		if prefix != c.expected {
+			t.Errorf("%s: expected %q, got %q", c.name, c.expected, prefix)
+		}
+	}
+}
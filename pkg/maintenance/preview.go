@@ -0,0 +1,87 @@
+package maintenance
+
+import (
+	"sort"
+	"time"
+
+	api "github.com/appscode/stash/apis/stash/v1alpha1"
+	"github.com/appscode/stash/pkg/cli"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SnapshotDecision is the result of evaluating a retention policy for a snapshot.
+type SnapshotDecision struct {
+	ID                  string    `json:"id"`
+	Time                time.Time `json:"time"`
+	Hostname            string    `json:"hostname"`
+	Paths               []string  `json:"paths"`
+	Tags                []string  `json:"tags,omitempty"`
+	RetentionPolicyName string    `json:"retentionPolicyName,omitempty"`
+	Keep                bool      `json:"keep"`
+	// Rules of the retention policy that keep the snapshot, eg: "daily snapshot"
+	Matches []string `json:"matches,omitempty"`
+}
+
+// Preview evaluates retention policies of the Restic of the Repository against the current snapshots of the repository
+// without removing or locking any snapshot. It is run in the sidecar of the workload. If policy is not nil, it is evaluated for every file group instead.
+// Snapshots of paths that are not evaluated by any policy are not listed.
+func (c *Controller) Preview(policy *api.RetentionPolicy) ([]SnapshotDecision, error) {
+	repository, err := c.stashClient.StashV1alpha1().Repositories(c.opt.Namespace).Get(c.opt.RepositoryName, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	restic, err := c.stashClient.StashV1alpha1().Restics(c.opt.Namespace).Get(repository.Labels["restic"], metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	resticCLI, err := c.setupSidecarRestic(restic, repository)
+	if err != nil {
+		return nil, err
+	}
+
+	decisions := make([]SnapshotDecision, 0)
+	for _, fg := range restic.Spec.FileGroups {
+		p := policy
+		if p == nil {
+			if p = retentionPolicy(restic, fg); p == nil {
+				continue
+			}
+		}
+		dryRun := *p
+		dryRun.DryRun = true
+
+		groups, err := resticCLI.Forget(fg.Path, dryRun)
+		if err != nil {
+			return nil, err
+		}
+		for _, g := range groups {
+			matches := map[string][]string{}
+			for _, reason := range g.Reasons {
+				matches[reason.Snapshot.ID] = reason.Matches
+			}
+			for _, snapshot := range g.Keep {
+				decisions = append(decisions, newSnapshotDecision(snapshot, p.Name, true, matches[snapshot.ID]))
+			}
+			for _, snapshot := range g.Remove {
+				decisions = append(decisions, newSnapshotDecision(snapshot, p.Name, false, nil))
+			}
+		}
+	}
+	sort.Slice(decisions, func(i, j int) bool {
+		return decisions[i].Time.After(decisions[j].Time)
+	})
+	return decisions, nil
+}
+
+func newSnapshotDecision(snapshot cli.Snapshot, policyName string, keep bool, matches []string) SnapshotDecision {
+	return SnapshotDecision{
+		ID:                  snapshot.ID,
+		Time:                snapshot.Time,
+		Hostname:            snapshot.Hostname,
+		Paths:               snapshot.Paths,
+		Tags:                snapshot.Tags,
+		RetentionPolicyName: policyName,
+		Keep:                keep,
+		Matches:             matches,
+	}
+}
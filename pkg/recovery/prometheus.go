@@ -0,0 +1,56 @@
+package recovery
+
+import (
+	"github.com/appscode/go/log"
+	api "github.com/appscode/stash/apis/stash/v1alpha1"
+	"github.com/appscode/stash/pkg/cli"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+)
+
+// pushMetrics pushes the result of recovery to pushgateway.
+func (c *Controller) pushMetrics(recovery *api.Recovery, err error) {
+	var (
+		restic_recovery_success = prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "restic",
+			Subsystem: "recovery",
+			Name:      "success",
+			Help:      "Indicates if recovery was successfully completed",
+		})
+		restic_recovery_fail = prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "restic",
+			Subsystem: "recovery",
+			Name:      "fail",
+			Help:      "Indicates if recovery failed",
+		})
+		restic_recovery_error = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "restic",
+			Subsystem: "recovery",
+			Name:      "error",
+			Help:      "Indicates the type of error that failed recovery",
+		}, []string{"type"})
+	)
+	for _, t := range cli.ErrorTypes {
+		restic_recovery_error.WithLabelValues(string(t)).Set(0)
+	}
+	if err != nil {
+		restic_recovery_success.Set(0)
+		restic_recovery_fail.Set(1)
+		restic_recovery_error.WithLabelValues(string(cli.ErrorTypeOf(err))).Set(1)
+	} else {
+		restic_recovery_success.Set(1)
+		restic_recovery_fail.Set(0)
+	}
+
+	labels := map[string]string{
+		"namespace": recovery.Namespace,
+		"recovery":  recovery.Name,
+	}
+	if perr := push.Collectors(recovery.Namespace+"-"+recovery.Name, labels, c.pushgatewayURL,
+		restic_recovery_success,
+		restic_recovery_fail,
+		restic_recovery_error,
+	); perr != nil {
+		log.Errorf("Failed to push metrics of recovery %s to %s, reason: %s\n", recovery.Name, c.pushgatewayURL, perr)
+	}
+}
@@ -11,29 +11,40 @@ import (
 	stash_util "github.com/appscode/stash/client/clientset/versioned/typed/stash/v1alpha1/util"
 	"github.com/appscode/stash/pkg/cli"
 	"github.com/appscode/stash/pkg/eventer"
+	"github.com/appscode/stash/pkg/hooks"
+	"github.com/appscode/stash/pkg/util"
+	"github.com/pkg/errors"
 	core "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/reference"
 )
 
 type Controller struct {
-	k8sClient    kubernetes.Interface
-	stashClient  cs.StashV1alpha1Interface
-	namespace    string
-	recoveryName string
+	k8sClient      kubernetes.Interface
+	stashClient    cs.StashV1alpha1Interface
+	hooks          *hooks.Executor
+	namespace      string
+	recoveryName   string
+	pushgatewayURL string
 }
 
 const (
 	RecoveryEventComponent = "stash-recovery"
+
+	// Minimum interval between progress updates of a running restore
+	progressUpdateInterval = 30 * time.Second
 )
 
-func New(k8sClient kubernetes.Interface, stashClient cs.StashV1alpha1Interface, namespace, name string) *Controller {
+func New(k8sClient kubernetes.Interface, stashClient cs.StashV1alpha1Interface, config *rest.Config, namespace, name, pushgatewayURL string) *Controller {
 	return &Controller{
-		k8sClient:    k8sClient,
-		stashClient:  stashClient,
-		namespace:    namespace,
-		recoveryName: name,
+		k8sClient:      k8sClient,
+		stashClient:    stashClient,
+		hooks:          hooks.New(k8sClient, config),
+		namespace:      namespace,
+		recoveryName:   name,
+		pushgatewayURL: pushgatewayURL,
 	}
 }
 
@@ -43,6 +54,11 @@ func (c *Controller) Run() {
 		log.Errorln(err)
 		return
 	}
+	defer func() {
+		if c.pushgatewayURL != "" {
+			c.pushMetrics(recovery, err)
+		}
+	}()
 
 	if err = recovery.IsValid(); err != nil {
 		log.Errorf("Failed to validate recovery %s, reason: %s\n", recovery.Name, err)
@@ -71,7 +87,7 @@ func (c *Controller) Run() {
 				RecoveryEventComponent,
 				ref,
 				core.EventTypeWarning,
-				eventer.EventReasonFailedToRecover,
+				eventer.ReasonForError(err, eventer.EventReasonFailedToRecover),
 				fmt.Sprintf("Failed to complete recovery %s, reason: %s", recovery.Name, err),
 			)
 		}
@@ -79,7 +95,7 @@ func (c *Controller) Run() {
 	}
 
 	log.Infof("Recovery %s succeeded\n", recovery.Name)
-	stash_util.SetRecoveryStatusPhase(c.stashClient, recovery, api.RecoverySucceeded) // TODO: status.Stats
+	stash_util.SetRecoveryStatusPhase(c.stashClient, recovery, api.RecoverySucceeded)
 	ref, rerr := reference.GetReference(scheme.Scheme, recovery)
 	if rerr == nil {
 		eventer.CreateEventWithLog(
@@ -106,14 +122,20 @@ func (c *Controller) RecoverOrErr(recovery *api.Recovery) error {
 		return err
 	}
 
-	cli := cli.New("/tmp", false, hostname)
-	if _, err = cli.SetupEnv(recovery.Spec.Backend, secret, smartPrefix); err != nil {
+	resticCLI := cli.New("/tmp", false, hostname)
+	if _, err = resticCLI.SetupEnv(recovery.Spec.Backend, secret, smartPrefix); err != nil {
 		return err
 	}
 
 	var errRec error
 	for _, path := range recovery.Spec.Paths {
-		d, err := c.measure(cli.Restore, path, hostname)
+		progress := cli.ThrottleProgress(progressUpdateInterval, func(p cli.Progress) {
+			stash_util.PatchRecovery(c.stashClient, recovery, func(in *api.Recovery) *api.Recovery {
+				in.Status.Progress = util.NewResticProgress(path, p)
+				return in
+			})
+		})
+		d, err := c.measure(resticCLI.WithProgress(progress).Restore, path, hostname)
 		if err != nil {
 			errRec = err
 			ref, rerr := reference.GetReference(scheme.Scheme, recovery)
@@ -123,7 +145,7 @@ func (c *Controller) RecoverOrErr(recovery *api.Recovery) error {
 					RecoveryEventComponent,
 					ref,
 					core.EventTypeWarning,
-					eventer.EventReasonFailedToRecover,
+					eventer.ReasonForError(err, eventer.EventReasonFailedToRecover),
 					fmt.Sprintf("failed to recover FileGroup %s, reason: %v", path, err),
 				)
 			}
@@ -132,8 +154,54 @@ func (c *Controller) RecoverOrErr(recovery *api.Recovery) error {
 			stash_util.SetRecoveryStats(c.stashClient, recovery, path, d, api.RecoverySucceeded)
 		}
 	}
+	if errRec != nil {
+		return errRec
+	}
+
+	return c.runPostRestoreHooks(recovery)
+}
+
+// runPostRestoreHooks executes hooks in order and records their results in status of recovery.
+// Returns error of the first failed hook, remaining hooks are not executed.
+func (c *Controller) runPostRestoreHooks(recovery *api.Recovery) error {
+	var results []api.HookResult
+	for _, hook := range recovery.Spec.PostRestoreHooks {
+		output, err := c.hooks.ExecuteRecoveryHook(hook, c.namespace)
+
+		result := api.HookResult{
+			Name:     hook.Name,
+			ExitCode: hooks.ExitCode(err),
+			Output:   output,
+		}
+		eventType, reason := core.EventTypeNormal, eventer.EventReasonSuccessfulHook
+		msg := fmt.Sprintf("post-restore hook %s succeeded", hook.Name)
+		if err != nil {
+			result.Error = err.Error()
+			eventType, reason = core.EventTypeWarning, eventer.EventReasonFailedHook
+			msg = fmt.Sprintf("post-restore hook %s failed, reason: %s", hook.Name, err)
+		}
+		results = append(results, result)
+		stash_util.PatchRecovery(c.stashClient, recovery, func(in *api.Recovery) *api.Recovery {
+			in.Status.HookResults = results
+			return in
+		})
+		ref, rerr := reference.GetReference(scheme.Scheme, recovery)
+		if rerr == nil {
+			eventer.CreateEventWithLog(
+				c.k8sClient,
+				RecoveryEventComponent,
+				ref,
+				eventType,
+				reason,
+				msg,
+			)
+		}
 
-	return errRec
+		if err != nil {
+			return errors.Wrapf(err, "post-restore hook %s failed", hook.Name)
+		}
+	}
+	return nil
 }
 
 func (c *Controller) measure(f func(string, string) error, path, host string) (time.Duration, error) {
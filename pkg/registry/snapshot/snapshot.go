@@ -4,6 +4,7 @@ import (
 	api "github.com/appscode/stash/apis/repositories/v1alpha1"
 	"github.com/appscode/stash/apis/stash/v1alpha1"
 	"github.com/appscode/stash/client/clientset/versioned"
+	"github.com/appscode/stash/pkg/util"
 	"github.com/pkg/errors"
 	metainternalversion "k8s.io/apimachinery/pkg/apis/meta/internalversion"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -63,7 +64,7 @@ func (r *REST) Get(ctx apirequest.Context, name string, options *metav1.GetOptio
 	}
 
 	snapshots := make([]api.Snapshot, 0)
-	if repo.Spec.Backend.Local != nil {
+	if util.BackendRequiresVolume(repo.Spec.Backend) {
 		snapshots, err = r.getSnapshotsFromSidecar(repo, []string{snapshotId})
 	} else {
 		snapshots, err = r.GetSnapshots(repo, []string{snapshotId})
@@ -114,7 +115,7 @@ func (r *REST) List(ctx apirequest.Context, options *metainternalversion.ListOpt
 	snapshotList := &api.SnapshotList{}
 	snapshots := make([]api.Snapshot, 0)
 	for _, repo := range selectedRepos {
-		if repo.Spec.Backend.Local != nil {
+		if util.BackendRequiresVolume(repo.Spec.Backend) {
 			snapshots, err = r.getSnapshotsFromSidecar(&repo, nil)
 			if err != nil {
 				return nil, err
@@ -148,7 +149,7 @@ func (r *REST) Delete(ctx apirequest.Context, name string) (runtime.Object, erro
 		return nil, errors.New("respective repository not found. error:" + err.Error())
 	}
 
-	if repo.Spec.Backend.Local != nil {
+	if util.BackendRequiresVolume(repo.Spec.Backend) {
 		err = r.forgetSnapshotsFromSidecar(repo, []string{snapshotId})
 	} else {
 		err = r.ForgetSnapshots(repo, []string{snapshotId})
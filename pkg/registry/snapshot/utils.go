@@ -50,7 +50,9 @@ func (r *REST) GetSnapshots(repository *v1alpha1.Repository, snapshotIDs []strin
 		return nil, err
 	}
 
-	backend = fixBackendPrefix(backend, smartPrefix)
+	if backend, err = fixBackendPrefix(backend, smartPrefix); err != nil {
+		return nil, err
+	}
 
 	cli := cli.New("/tmp", false, hostName)
 	if _, err = cli.SetupEnv(*backend, secret, smartPrefix); err != nil {
@@ -105,7 +107,9 @@ func (r *REST) ForgetSnapshots(repository *v1alpha1.Repository, snapshotIDs []st
 		return err
 	}
 
-	backend = fixBackendPrefix(backend, smartPrefix)
+	if backend, err = fixBackendPrefix(backend, smartPrefix); err != nil {
+		return err
+	}
 
 	cli := cli.New("/tmp", false, hostName)
 	if _, err = cli.SetupEnv(*backend, secret, smartPrefix); err != nil {
@@ -190,30 +194,13 @@ func extractDataFromRepositoryLabel(labels map[string]string) (info labelsInfo,
 	return info, nil
 }
 
-func fixBackendPrefix(backend *v1alpha1.Backend, autoPrefix string) *v1alpha1.Backend {
-	if backend.Local != nil {
-		backend.Local.SubPath = strings.TrimSuffix(backend.Local.SubPath, autoPrefix)
-		backend.Local.SubPath = strings.TrimSuffix(backend.Local.SubPath, "/")
-	} else if backend.S3 != nil {
-		backend.S3.Prefix = strings.TrimSuffix(backend.S3.Prefix, autoPrefix)
-		backend.S3.Prefix = strings.TrimSuffix(backend.S3.Prefix, "/")
-		backend.S3.Prefix = strings.TrimPrefix(backend.S3.Prefix, backend.S3.Bucket)
-		backend.S3.Prefix = strings.TrimPrefix(backend.S3.Prefix, "/")
-	} else if backend.GCS != nil {
-		backend.GCS.Prefix = strings.TrimSuffix(backend.GCS.Prefix, autoPrefix)
-		backend.GCS.Prefix = strings.TrimSuffix(backend.GCS.Prefix, "/")
-	} else if backend.Azure != nil {
-		backend.Azure.Prefix = strings.TrimSuffix(backend.Azure.Prefix, autoPrefix)
-		backend.Azure.Prefix = strings.TrimSuffix(backend.Azure.Prefix, "/")
-	} else if backend.Swift != nil {
-		backend.Swift.Prefix = strings.TrimSuffix(backend.Swift.Prefix, autoPrefix)
-		backend.Swift.Prefix = strings.TrimSuffix(backend.Swift.Prefix, "/")
-	} else if backend.B2 != nil {
-		backend.B2.Prefix = strings.TrimSuffix(backend.B2.Prefix, autoPrefix)
-		backend.B2.Prefix = strings.TrimSuffix(backend.B2.Prefix, "/")
-	}
-
-	return backend
+func fixBackendPrefix(backend *v1alpha1.Backend, autoPrefix string) (*v1alpha1.Backend, error) {
+	provider, err := cli.GetBackendProvider(backend)
+	if err != nil {
+		return nil, err
+	}
+	provider.TrimPrefix(backend, autoPrefix)
+	return backend, nil
 }
 
 func (r *REST) getPodWithStashSidecar(namespace, workloadname string) (*core.Pod, error) {
@@ -3,6 +3,7 @@ package scale
 import (
 	"fmt"
 	"strconv"
+	"time"
 
 	"github.com/appscode/go/log"
 	"github.com/appscode/go/types"
@@ -11,40 +12,56 @@ import (
 	ext_util "github.com/appscode/kutil/extensions/v1beta1"
 	meta_util "github.com/appscode/kutil/meta"
 	api "github.com/appscode/stash/apis/stash/v1alpha1"
+	cs "github.com/appscode/stash/client/clientset/versioned"
 	"github.com/appscode/stash/pkg/backup"
+	"github.com/appscode/stash/pkg/eventer"
 	"github.com/appscode/stash/pkg/util"
 	apps "k8s.io/api/apps/v1beta1"
 	core "k8s.io/api/core/v1"
 	extensions "k8s.io/api/extensions/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/reference"
 )
 
 type Options struct {
-	Workload  api.LocalTypedReference
-	Namespace string
-	Selector  string
+	Workload   api.LocalTypedReference
+	Namespace  string
+	Selector   string
+	ResticName string
 }
 
 type Controller struct {
-	k8sClient kubernetes.Interface
-	opt       Options
-	locked    chan struct{}
+	k8sClient   kubernetes.Interface
+	stashClient cs.Interface
+	opt         Options
+	locked      chan struct{}
 }
 
+const (
+	ScaledownEventComponent = "stash-scaledown"
+)
+
 var (
 	ZeroReplica = int32(0)
 	OneReplica  = int32(1)
 )
 
-func New(k8sClient kubernetes.Interface, opt Options) *Controller {
+func New(k8sClient kubernetes.Interface, stashClient cs.Interface, opt Options) *Controller {
 	return &Controller{
-		k8sClient: k8sClient,
-		opt:       opt,
+		k8sClient:   k8sClient,
+		stashClient: stashClient,
+		opt:         opt,
 	}
 }
 
 func (c *Controller) ScaleDownWorkload() error {
+	if skip, err := c.inBlackoutWindow(); err != nil {
+		return err
+	} else if skip {
+		return nil
+	}
 
 	// scale down deployment to 0 replica
 	dpList, err := c.k8sClient.AppsV1beta1().Deployments(c.opt.Namespace).List(metav1.ListOptions{LabelSelector: c.opt.Selector})
@@ -233,3 +250,30 @@ func ScaleUpWorkload(k8sClient *kubernetes.Clientset, opt backup.Options) error
 
 	return nil
 }
+
+// inBlackoutWindow returns true and records an event on Restic if Restic is in a blackout window now.
+func (c *Controller) inBlackoutWindow() (bool, error) {
+	if c.opt.ResticName == "" {
+		return false, nil
+	}
+	restic, err := c.stashClient.StashV1alpha1().Restics(c.opt.Namespace).Get(c.opt.ResticName, metav1.GetOptions{})
+	if err != nil {
+		return false, err
+	}
+	w, err := restic.Spec.ActiveBlackoutWindow(time.Now())
+	if err != nil || w == nil {
+		return false, err
+	}
+	ref, rerr := reference.GetReference(scheme.Scheme, restic)
+	if rerr == nil {
+		eventer.CreateEventWithLog(
+			c.k8sClient,
+			ScaledownEventComponent,
+			ref,
+			core.EventTypeNormal,
+			eventer.EventReasonSkippedBackup,
+			fmt.Sprintf("Skipped offline backup during blackout window %s", w),
+		)
+	}
+	return true, nil
+}
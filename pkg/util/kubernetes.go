@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"reflect"
 	"strings"
+	"time"
 
 	"github.com/appscode/go/log/golog"
 	core_util "github.com/appscode/kutil/core/v1"
@@ -12,6 +13,7 @@ import (
 	"github.com/appscode/kutil/tools/analytics"
 	api "github.com/appscode/stash/apis/stash/v1alpha1"
 	stash_listers "github.com/appscode/stash/client/listers/stash/v1alpha1"
+	"github.com/appscode/stash/pkg/cli"
 	"github.com/appscode/stash/pkg/docker"
 	"github.com/pkg/errors"
 	batch "k8s.io/api/batch/v1"
@@ -28,17 +30,20 @@ const (
 	ScratchDirVolumeName = "stash-scratchdir"
 	PodinfoVolumeName    = "stash-podinfo"
 
-	RecoveryJobPrefix   = "stash-recovery-"
-	ScaledownCronPrefix = "stash-scaledown-cron-"
-	CheckJobPrefix      = "stash-check-"
+	RecoveryJobPrefix     = "stash-recovery-"
+	ScaledownCronPrefix   = "stash-scaledown-cron-"
+	CheckJobPrefix        = "stash-check-"
+	MaintenanceCronPrefix = "stash-maintenance-"
 
 	AnnotationRestic     = "restic"
+	AnnotationRepository = "repository"
 	AnnotationRecovery   = "recovery"
 	AnnotationOperation  = "operation"
 	AnnotationOldReplica = "old-replica"
 
-	OperationRecovery = "recovery"
-	OperationCheck    = "check"
+	OperationRecovery    = "recovery"
+	OperationCheck       = "check"
+	OperationMaintenance = "maintenance"
 
 	AppLabelStash      = "stash"
 	OperationScaleDown = "scale-down"
@@ -194,9 +199,8 @@ func NewSidecarContainer(r *api.Restic, workload api.LocalTypedReference, image
 			ReadOnly:  true,
 		})
 	}
-	if r.Spec.Backend.Local != nil {
-		_, mnt := r.Spec.Backend.Local.ToVolumeAndMount(LocalVolumeName)
-		sidecar.VolumeMounts = append(sidecar.VolumeMounts, mnt)
+	if _, mnt := BackendVolumeAndMount(r.Spec.Backend); mnt != nil {
+		sidecar.VolumeMounts = append(sidecar.VolumeMounts, *mnt)
 	}
 	return sidecar
 }
@@ -231,7 +235,7 @@ func UpsertDownwardVolume(volumes []core.Volume) []core.Volume {
 
 func MergeLocalVolume(volumes []core.Volume, old, new *api.Restic) []core.Volume {
 	oldPos := -1
-	if old != nil && old.Spec.Backend.Local != nil {
+	if old != nil && BackendRequiresVolume(old.Spec.Backend) {
 		for i, vol := range volumes {
 			if vol.Name == LocalVolumeName {
 				oldPos = i
@@ -239,12 +243,11 @@ func MergeLocalVolume(volumes []core.Volume, old, new *api.Restic) []core.Volume
 			}
 		}
 	}
-	if new.Spec.Backend.Local != nil {
-		vol, _ := new.Spec.Backend.Local.ToVolumeAndMount(LocalVolumeName)
+	if vol, _ := BackendVolumeAndMount(new.Spec.Backend); vol != nil {
 		if oldPos != -1 {
-			volumes[oldPos] = vol
+			volumes[oldPos] = *vol
 		} else {
-			volumes = core_util.UpsertVolume(volumes, vol)
+			volumes = core_util.UpsertVolume(volumes, *vol)
 		}
 	} else {
 		if oldPos != -1 {
@@ -254,6 +257,36 @@ func MergeLocalVolume(volumes []core.Volume, old, new *api.Restic) []core.Volume
 	return volumes
 }
 
+// BackendVolumeAndMount returns the volume and mount required by stash container to access backend.
+// Volume is always named LocalVolumeName. Returns nil if backend does not require any volume.
+func BackendVolumeAndMount(backend api.Backend) (*core.Volume, *core.VolumeMount) {
+	provider, err := cli.GetBackendProvider(&backend)
+	if err != nil {
+		return nil, nil
+	}
+	return provider.VolumeAndMount(&backend, LocalVolumeName)
+}
+
+func BackendRequiresVolume(backend api.Backend) bool {
+	vol, _ := BackendVolumeAndMount(backend)
+	return vol != nil
+}
+
+// NewResticProgress converts a status message printed by restic while processing FileGroup path.
+func NewResticProgress(path string, p cli.Progress) *api.ResticProgress {
+	now := metav1.Now()
+	return &api.ResticProgress{
+		Path:           path,
+		PercentDone:    int32(p.PercentDone * 100),
+		TotalBytes:     p.TotalBytes,
+		BytesDone:      p.BytesDone,
+		TotalFiles:     p.TotalFiles,
+		FilesDone:      p.FilesDone,
+		ETA:            (time.Duration(p.SecondsRemaining) * time.Second).String(),
+		LastUpdateTime: &now,
+	}
+}
+
 func EnsureVolumeDeleted(volumes []core.Volume, name string) []core.Volume {
 	for i, v := range volumes {
 		if v.Name == name {
@@ -322,6 +355,7 @@ func NewRecoveryJob(recovery *api.Recovery, image docker.Docker) *batch.Job {
 							Args: append([]string{
 								"recover",
 								"--recovery-name=" + recovery.Name,
+								"--pushgateway-url=" + PushgatewayURL(),
 								fmt.Sprintf("--enable-analytics=%v", EnableAnalytics),
 							}, LoggerOptions.ToFlags()...),
 							Env: []core.EnvVar{
@@ -351,11 +385,10 @@ func NewRecoveryJob(recovery *api.Recovery, image docker.Docker) *batch.Job {
 	}
 
 	// local backend
-	if recovery.Spec.Backend.Local != nil {
-		vol, mnt := recovery.Spec.Backend.Local.ToVolumeAndMount(LocalVolumeName)
+	if vol, mnt := BackendVolumeAndMount(recovery.Spec.Backend); vol != nil {
 		job.Spec.Template.Spec.Containers[0].VolumeMounts = append(
-			job.Spec.Template.Spec.Containers[0].VolumeMounts, mnt)
-		job.Spec.Template.Spec.Volumes = append(job.Spec.Template.Spec.Volumes, vol)
+			job.Spec.Template.Spec.Containers[0].VolumeMounts, *mnt)
+		job.Spec.Template.Spec.Volumes = append(job.Spec.Template.Spec.Volumes, *vol)
 	}
 
 	return job
@@ -388,6 +421,65 @@ func WorkloadExists(k8sClient kubernetes.Interface, namespace string, workload a
 	return nil
 }
 
+// WorkloadPodSelector returns the selector of the pods of workload.
+func WorkloadPodSelector(k8sClient kubernetes.Interface, namespace string, workload api.LocalTypedReference) (labels.Selector, error) {
+	if err := workload.Canonicalize(); err != nil {
+		return nil, err
+	}
+
+	var (
+		selector *metav1.LabelSelector
+		template map[string]string
+	)
+	switch workload.Kind {
+	case api.KindDeployment:
+		obj, err := k8sClient.AppsV1beta1().Deployments(namespace).Get(workload.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		selector, template = obj.Spec.Selector, obj.Spec.Template.Labels
+	case api.KindReplicaSet:
+		obj, err := k8sClient.ExtensionsV1beta1().ReplicaSets(namespace).Get(workload.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		selector, template = obj.Spec.Selector, obj.Spec.Template.Labels
+	case api.KindReplicationController:
+		obj, err := k8sClient.CoreV1().ReplicationControllers(namespace).Get(workload.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		if len(obj.Spec.Selector) > 0 {
+			return labels.SelectorFromSet(obj.Spec.Selector), nil
+		}
+		if obj.Spec.Template != nil {
+			template = obj.Spec.Template.Labels
+		}
+	case api.KindStatefulSet:
+		obj, err := k8sClient.AppsV1beta1().StatefulSets(namespace).Get(workload.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		selector, template = obj.Spec.Selector, obj.Spec.Template.Labels
+	case api.KindDaemonSet:
+		obj, err := k8sClient.ExtensionsV1beta1().DaemonSets(namespace).Get(workload.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		selector, template = obj.Spec.Selector, obj.Spec.Template.Labels
+	default:
+		return nil, fmt.Errorf(`unrecognized workload "Kind" %v`, workload.Kind)
+	}
+	// selector defaults to the labels of the pod template
+	if selector == nil {
+		if len(template) == 0 {
+			return labels.Nothing(), nil
+		}
+		return labels.SelectorFromSet(template), nil
+	}
+	return metav1.LabelSelectorAsSelector(selector)
+}
+
 func GetConfigmapLockName(workload api.LocalTypedReference) string {
 	return strings.ToLower(fmt.Sprintf("lock-%s-%s", workload.Kind, workload.Name))
 }
@@ -396,7 +488,7 @@ func DeleteConfigmapLock(k8sClient kubernetes.Interface, namespace string, workl
 	return k8sClient.CoreV1().ConfigMaps(namespace).Delete(GetConfigmapLockName(workload), &metav1.DeleteOptions{})
 }
 
-func NewCheckJob(restic *api.Restic, hostName, smartPrefix string, image docker.Docker) *batch.Job {
+func NewCheckJob(restic *api.Restic, workload api.LocalTypedReference, hostName, smartPrefix, pushgatewayURL string, image docker.Docker) *batch.Job {
 	job := &batch.Job{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      CheckJobPrefix + restic.Name,
@@ -425,8 +517,11 @@ func NewCheckJob(restic *api.Restic, hostName, smartPrefix string, image docker.
 							Args: append([]string{
 								"check",
 								"--restic-name=" + restic.Name,
+								"--workload-kind=" + workload.Kind,
+								"--workload-name=" + workload.Name,
 								"--host-name=" + hostName,
 								"--smart-prefix=" + smartPrefix,
+								"--pushgateway-url=" + pushgatewayURL,
 								fmt.Sprintf("--enable-analytics=%v", EnableAnalytics),
 							}, LoggerOptions.ToFlags()...),
 							Env: []core.EnvVar{
@@ -460,11 +555,10 @@ func NewCheckJob(restic *api.Restic, hostName, smartPrefix string, image docker.
 
 	// local backend
 	// user don't need to specify "stash-local" volume, we collect it from restic-spec
-	if restic.Spec.Backend.Local != nil {
-		vol, mnt := restic.Spec.Backend.Local.ToVolumeAndMount(LocalVolumeName)
+	if vol, mnt := BackendVolumeAndMount(restic.Spec.Backend); vol != nil {
 		job.Spec.Template.Spec.Containers[0].VolumeMounts = append(
-			job.Spec.Template.Spec.Containers[0].VolumeMounts, mnt)
-		job.Spec.Template.Spec.Volumes = append(job.Spec.Template.Spec.Volumes, vol)
+			job.Spec.Template.Spec.Containers[0].VolumeMounts, *mnt)
+		job.Spec.Template.Spec.Volumes = append(job.Spec.Template.Spec.Volumes, *vol)
 	}
 
 	return job
@@ -499,3 +593,17 @@ func WorkloadReplicas(kubeClient *kubernetes.Clientset, namespace string, worklo
 	}
 	return 0, nil
 }
+
+// FormatBytes returns size in human readable binary units, eg: 1.5 GiB
+func FormatBytes(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
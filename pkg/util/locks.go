@@ -0,0 +1,196 @@
+package util
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+
+	api "github.com/appscode/stash/apis/stash/v1alpha1"
+	"github.com/appscode/stash/pkg/cli"
+	core "k8s.io/api/core/v1"
+	kerr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// StaleLock is a lock of a restic process that no longer runs, or that was not refreshed within the stale lock timeout.
+type StaleLock struct {
+	cli.Lock
+	Reason string
+}
+
+func (l StaleLock) String() string {
+	return fmt.Sprintf("%s: %s", l.Lock, l.Reason)
+}
+
+// RemoveStaleLocks removes stale locks of the repository of resticCLI. A lock is stale if it was taken in this pod and
+// its process no longer runs, or if it was not refreshed within staleLockTimeout of policy and its host is not a running
+// pod of workload, eg: the pod was evicted. If restic removes exactly the stale locks, they are removed by restic, which
+// checks each lock again when removing it. Otherwise, if all locks are stale, the locks are listed again and all locks
+// are removed if no lock was taken meanwhile. Nothing is removed if a lock that is not stale would be removed too, eg:
+// an old lock of a running pod on a node with skewed clock. Returns the removed locks.
+func RemoveStaleLocks(k8sClient kubernetes.Interface, resticCLI *cli.ResticWrapper, namespace string, workload api.LocalTypedReference, policy *api.ExecutionPolicy) ([]StaleLock, error) {
+	locks, err := resticCLI.ListLocks()
+	if err != nil || len(locks) == 0 {
+		return nil, err
+	}
+	running, err := runningWorkloadHosts(k8sClient, namespace, workload)
+	if err != nil {
+		return nil, err
+	}
+	hostname, _ := os.Hostname()
+
+	stale, removeAll := staleLocks(locks, hostname, running, staleLockTimeout(policy), time.Now())
+	if len(stale) == 0 {
+		return nil, nil
+	}
+	if removeAll {
+		// restic does not check locks when removing all of them, so locks taken since listing must be kept
+		if locks, err = resticCLI.ListLocks(); err != nil {
+			return nil, err
+		}
+		ids := map[string]bool{}
+		for _, lock := range stale {
+			ids[lock.ID] = true
+		}
+		for _, lock := range locks {
+			if !ids[lock.ID] {
+				return nil, nil
+			}
+		}
+		err = resticCLI.RemoveAllLocks()
+	} else {
+		err = resticCLI.RemoveStaleLocks()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// report the locks that were removed
+	locks, err = resticCLI.ListLocks()
+	if err != nil {
+		return nil, err
+	}
+	remaining := map[string]bool{}
+	for _, lock := range locks {
+		remaining[lock.ID] = true
+	}
+	removed := make([]StaleLock, 0, len(stale))
+	for _, lock := range stale {
+		if !remaining[lock.ID] {
+			removed = append(removed, lock)
+		}
+	}
+	return removed, nil
+}
+
+// runningWorkloadHosts returns the hostnames of the running pods of workload. A deleted workload has no running pods.
+func runningWorkloadHosts(k8sClient kubernetes.Interface, namespace string, workload api.LocalTypedReference) (map[string]bool, error) {
+	if workload.Name == "" {
+		return map[string]bool{}, nil
+	}
+	selector, err := WorkloadPodSelector(k8sClient, namespace, workload)
+	if kerr.IsNotFound(err) {
+		return map[string]bool{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	pods, err := k8sClient.CoreV1().Pods(namespace).List(metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, err
+	}
+	return runningHosts(pods.Items), nil
+}
+
+// runningHosts returns the hostnames of the running pods. Pods with host network report the name of their node.
+func runningHosts(pods []core.Pod) map[string]bool {
+	running := map[string]bool{}
+	for _, pod := range pods {
+		if pod.Status.Phase == core.PodSucceeded || pod.Status.Phase == core.PodFailed {
+			continue
+		}
+		running[pod.Name] = true
+		if pod.Spec.Hostname != "" {
+			running[pod.Spec.Hostname] = true
+		}
+		if pod.Spec.HostNetwork && pod.Spec.NodeName != "" {
+			running[pod.Spec.NodeName] = true
+		}
+	}
+	return running
+}
+
+// staleLockTimeout returns the duration after which a lock of another host that was not refreshed is stale.
+func staleLockTimeout(policy *api.ExecutionPolicy) time.Duration {
+	if policy != nil && policy.StaleLockTimeout != nil && policy.StaleLockTimeout.Duration > api.MinStaleLockTimeout {
+		return policy.StaleLockTimeout.Duration
+	}
+	return api.MinStaleLockTimeout
+}
+
+// staleLocks returns the stale locks of locks, and whether all locks have to be removed to remove them, because restic
+// would not remove some of them. No lock is returned if a lock that is not stale would be removed too.
+func staleLocks(locks []cli.Lock, hostname string, running map[string]bool, timeout time.Duration, now time.Time) ([]StaleLock, bool) {
+	var (
+		stale    []StaleLock
+		byRestic = true
+		unsafe   bool
+	)
+	for _, lock := range locks {
+		reason, removable := staleLockReason(lock, hostname, running, timeout, now)
+		if reason == "" {
+			unsafe = unsafe || removable
+			continue
+		}
+		byRestic = byRestic && removable
+		stale = append(stale, StaleLock{Lock: lock, Reason: reason})
+	}
+	switch {
+	case len(stale) == 0:
+		return nil, false
+	case byRestic && !unsafe:
+		return stale, false
+	case len(stale) == len(locks):
+		return stale, true
+	}
+	return nil, false
+}
+
+// staleLockReason returns why lock is stale, empty if it is not, and whether restic would remove the lock on unlock.
+// A host that is not a running pod of the workload, eg: a pod of a check job, is not considered dead, so its locks are
+// only stale after timeout.
+func staleLockReason(lock cli.Lock, hostname string, running map[string]bool, timeout time.Duration, now time.Time) (string, bool) {
+	age := now.Sub(lock.Time)
+	dead := lock.Hostname == hostname && !processRunning(lock.PID)
+	removable := dead || age > cli.StaleLockAge
+	switch {
+	case dead:
+		return "process no longer runs", removable
+	case lock.Hostname == hostname || running[lock.Hostname]:
+		// a running pod refreshes its locks, an old lock may be caused by a skewed clock
+		return "", removable
+	case age > timeout:
+		return fmt.Sprintf("not refreshed within %s", timeout), removable
+	}
+	return "", removable
+}
+
+// StaleLocksMessage describes removed stale locks for events.
+func StaleLocksMessage(locks []StaleLock) string {
+	s := make([]string, 0, len(locks))
+	for _, lock := range locks {
+		s = append(s, lock.String())
+	}
+	return fmt.Sprintf("Removed stale locks of repository: %s", strings.Join(s, ", "))
+}
+
+// processRunning returns true if a process with pid runs in this pod.
+func processRunning(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	err := syscall.Kill(pid, 0)
+	return err == nil || err == syscall.EPERM
+}
@@ -0,0 +1,193 @@
+package util
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	api "github.com/appscode/stash/apis/stash/v1alpha1"
+	"github.com/appscode/stash/pkg/cli"
+	apps "k8s.io/api/apps/v1beta1"
+	core "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kfake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestStaleLockReason(t *testing.T) {
+	now := time.Date(2018, 4, 10, 10, 0, 0, 0, time.UTC)
+	running := map[string]bool{"stash-demo-0": true}
+	pid := os.Getpid()
+
+	cases := []struct {
+		name      string
+		lock      cli.Lock
+		timeout   time.Duration
+		stale     bool
+		removable bool
+	}{
+		{
+			name:      "process of this pod no longer runs",
+			lock:      cli.Lock{Hostname: "stash-demo-1", PID: 0, Time: now.Add(-time.Minute)},
+			timeout:   cli.StaleLockAge,
+			stale:     true,
+			removable: true,
+		},
+		{
+			name:    "process of this pod runs",
+			lock:    cli.Lock{Hostname: "stash-demo-1", PID: pid, Time: now.Add(-time.Minute)},
+			timeout: cli.StaleLockAge,
+		},
+		{
+			name:      "old lock of running process of this pod",
+			lock:      cli.Lock{Hostname: "stash-demo-1", PID: pid, Time: now.Add(-time.Hour)},
+			timeout:   cli.StaleLockAge,
+			removable: true,
+		},
+		{
+			name:    "recent lock of unknown host",
+			lock:    cli.Lock{Hostname: "node-1", PID: 12, Time: now.Add(-20 * time.Minute)},
+			timeout: cli.StaleLockAge,
+		},
+		{
+			name:      "old lock of unknown host",
+			lock:      cli.Lock{Hostname: "node-1", PID: 12, Time: now.Add(-time.Hour)},
+			timeout:   cli.StaleLockAge,
+			stale:     true,
+			removable: true,
+		},
+		{
+			name:      "old lock of unknown host within timeout",
+			lock:      cli.Lock{Hostname: "node-1", PID: 12, Time: now.Add(-time.Hour)},
+			timeout:   2 * time.Hour,
+			removable: true,
+		},
+		{
+			name:      "old lock of running pod",
+			lock:      cli.Lock{Hostname: "stash-demo-0", PID: 12, Time: now.Add(-time.Hour)},
+			timeout:   cli.StaleLockAge,
+			removable: true,
+		},
+	}
+	for _, c := range cases {
+		reason, removable := staleLockReason(c.lock, "stash-demo-1", running, c.timeout, now)
+		if stale := reason != ""; stale != c.stale {
+			t.Errorf("%s: expected stale %v, got %v (%q)", c.name, c.stale, stale, reason)
+		}
+		if removable != c.removable {
+			t.Errorf("%s: expected removable by restic %v, got %v", c.name, c.removable, removable)
+		}
+	}
+}
+
+func TestStaleLocks(t *testing.T) {
+	now := time.Date(2018, 4, 10, 10, 0, 0, 0, time.UTC)
+	running := map[string]bool{"stash-demo-0": true}
+	evicted := cli.Lock{ID: "evicted", Hostname: "stash-demo-1", PID: 12, Time: now.Add(-15 * time.Minute)}
+	old := cli.Lock{ID: "old", Hostname: "stash-demo-2", PID: 12, Time: now.Add(-time.Hour)}
+	recent := cli.Lock{ID: "recent", Hostname: "stash-demo-0", PID: 12, Time: now.Add(-time.Minute)}
+	skewed := cli.Lock{ID: "skewed", Hostname: "stash-demo-0", PID: 12, Time: now.Add(-time.Hour)}
+
+	cases := []struct {
+		name      string
+		locks     []cli.Lock
+		stale     []string
+		removeAll bool
+	}{
+		{"no lock is stale", []cli.Lock{recent}, nil, false},
+		{"restic removes stale locks", []cli.Lock{old, recent}, []string{"old"}, false},
+		{"lock of evicted pod is removed with all locks", []cli.Lock{evicted, old}, []string{"evicted", "old"}, true},
+		{"lock of evicted pod is kept while another lock is held", []cli.Lock{evicted, recent}, nil, false},
+		{"old lock of running pod is kept", []cli.Lock{old, skewed}, nil, false},
+	}
+	for _, c := range cases {
+		stale, removeAll := staleLocks(c.locks, "stash-demo-3", running, api.MinStaleLockTimeout, now)
+		var ids []string
+		for _, lock := range stale {
+			ids = append(ids, lock.ID)
+		}
+		if !reflect.DeepEqual(ids, c.stale) {
+			t.Errorf("%s: expected stale locks %v, got %v", c.name, c.stale, ids)
+		}
+		if removeAll != c.removeAll {
+			t.Errorf("%s: expected remove all %v, got %v", c.name, c.removeAll, removeAll)
+		}
+	}
+}
+
+func TestRunningWorkloadHosts(t *testing.T) {
+	labels := map[string]string{"app": "stash-demo"}
+	replicas := int32(2)
+	client := kfake.NewSimpleClientset(
+		&apps.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "stash-demo", Namespace: "default"},
+			Spec: apps.DeploymentSpec{
+				Replicas: &replicas,
+				Selector: &metav1.LabelSelector{MatchLabels: labels},
+			},
+		},
+		&core.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "stash-demo-0", Namespace: "default", Labels: labels},
+			Status:     core.PodStatus{Phase: core.PodRunning},
+		},
+		&core.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "stash-demo-1", Namespace: "default", Labels: labels},
+			Spec:       core.PodSpec{HostNetwork: true, NodeName: "node-1"},
+			Status:     core.PodStatus{Phase: core.PodRunning},
+		},
+		&core.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "stash-demo-2", Namespace: "default", Labels: labels},
+			Status:     core.PodStatus{Phase: core.PodFailed},
+		},
+		&core.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "other-0", Namespace: "default", Labels: map[string]string{"app": "other"}},
+			Status:     core.PodStatus{Phase: core.PodRunning},
+		},
+	)
+
+	cases := []struct {
+		workload api.LocalTypedReference
+		expected map[string]bool
+	}{
+		{
+			workload: api.LocalTypedReference{Kind: api.KindDeployment, Name: "stash-demo"},
+			expected: map[string]bool{"stash-demo-0": true, "stash-demo-1": true, "node-1": true},
+		},
+		{
+			workload: api.LocalTypedReference{Kind: api.KindDeployment, Name: "deleted"},
+			expected: map[string]bool{},
+		},
+		{
+			workload: api.LocalTypedReference{},
+			expected: map[string]bool{},
+		},
+	}
+	for _, c := range cases {
+		running, err := runningWorkloadHosts(client, "default", c.workload)
+		if err != nil {
+			t.Errorf("%+v: unexpected error %s", c.workload, err)
+			continue
+		}
+		if !reflect.DeepEqual(running, c.expected) {
+			t.Errorf("%+v: expected running hosts %v, got %v", c.workload, c.expected, running)
+		}
+	}
+}
+
+func TestStaleLockTimeout(t *testing.T) {
+	cases := []struct {
+		policy   *api.ExecutionPolicy
+		expected time.Duration
+	}{
+		{nil, api.MinStaleLockTimeout},
+		{&api.ExecutionPolicy{}, api.MinStaleLockTimeout},
+		{&api.ExecutionPolicy{StaleLockTimeout: &metav1.Duration{Duration: time.Minute}}, api.MinStaleLockTimeout},
+		{&api.ExecutionPolicy{StaleLockTimeout: &metav1.Duration{Duration: 15 * time.Minute}}, 15 * time.Minute},
+		{&api.ExecutionPolicy{StaleLockTimeout: &metav1.Duration{Duration: 2 * time.Hour}}, 2 * time.Hour},
+	}
+	for _, c := range cases {
+		if got := staleLockTimeout(c.policy); got != c.expected {
+			t.Errorf("%+v: expected %s, got %s", c.policy, c.expected, got)
+		}
+	}
+}